@@ -35,17 +35,6 @@ type getSuppliersTestSuite struct {
 	flagClient flagsup.ClientAdapter
 }
 
-func (ts *getSuppliersTestSuite) tearDown() {
-	err := helper.Truncate(ts.DB,
-		model.Supplier{}.TableName(),
-		model.SupplierContact{}.TableName(),
-		model.SupplierContactCategory{}.TableName(),
-	)
-	if err != nil {
-		ts.T().Fatal("Can't tear down DB", err)
-	}
-}
-
 func (ts *getSuppliersTestSuite) setUp() error {
 	orderSchedule, err := helper.ProtoMessageToJson(&api.OrderSchedule{
 		IsOrderableOnMonday:    true,
@@ -60,7 +49,6 @@ func (ts *getSuppliersTestSuite) setUp() error {
 		ts.T().Fatal("Can't generate orderSchedule JSON", err)
 	}
 
-	ts.tearDown()
 	ts.suppliers = make([]model.Supplier, 6)
 	ts.suppliers[0] = ts.Faker.SupplierInfo(1, 1, true)
 	ts.suppliers[1] = ts.Faker.SupplierInfo(1, 2, true)
@@ -106,8 +94,8 @@ func TestGetSuppliers(t *testing.T) {
 	ts := &getSuppliersTestSuite{}
 
 	db, logger = tests.InitIntegrationTestMain(t, db, logger)
-	ts.DB = db
-	ts.Faker = faker.New(db)
+	ts.DB = ts.IsolatedDB(t, db)
+	ts.Faker = faker.New(ts.DB)
 
 	err := ts.setUp()
 	if err != nil {
@@ -141,13 +129,13 @@ func TestGetSuppliers(t *testing.T) {
 		Config: &config.Config{
 			SellersOn811: "1,3",
 		},
-		DB:                 db,
-		SupplierRepository: repository.NewSupplierRepository(db),
+		DB:                 ts.DB,
+		SupplierRepository: repository.NewSupplierRepository(ts.DB),
 		SrmClient:          srmClient,
-		SupplierProvider:   provider.NewSupplierProvider(sellerServiceClient, flagClient, db),
+		SupplierProvider:   provider.NewSupplierProvider(sellerServiceClient, flagClient, ts.DB),
 	}
 
-	val := validator.NewValidator(db)
+	val := validator.NewValidator(ts.DB)
 	trans := transformer.NewTransformer()
 
 	baseServer := &rpcimpl.Server{
@@ -166,7 +154,6 @@ func TestGetSuppliers(t *testing.T) {
 			logger.Error(err, "could not close connection")
 		}
 		server.Stop()
-		ts.tearDown()
 	}()
 }
 
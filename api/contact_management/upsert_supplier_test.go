@@ -33,19 +33,7 @@ type UpsertSupplierInfoTestSuite struct {
 	Suppliers []model.Supplier
 }
 
-func (ts *UpsertSupplierInfoTestSuite) tearDown() {
-	err := helper.Truncate(ts.DB,
-		model.Supplier{}.TableName(),
-		model.SupplierContact{}.TableName(),
-		model.SupplierContactCategory{}.TableName(),
-	)
-	if err != nil {
-		ts.T().Fatal("Can't tear down DB", err)
-	}
-}
-
 func (ts *UpsertSupplierInfoTestSuite) setUp() error {
-	ts.tearDown()
 	ts.Suppliers = make([]model.Supplier, 1)
 	ts.Suppliers[0] = *ts.Faker.Supplier(ts.generateSupplier(100), true)
 	return nil
@@ -55,8 +43,8 @@ func TestUpsertSupplierInfo(t *testing.T) {
 	ts := &UpsertSupplierInfoTestSuite{}
 
 	db, logger = tests.InitIntegrationTestMain(t, db, logger)
-	ts.DB = db
-	ts.Faker = faker.New(db)
+	ts.DB = ts.IsolatedDB(t, db)
+	ts.Faker = faker.New(ts.DB)
 	ts.Context = context.Background()
 
 	err := ts.setUp()
@@ -91,18 +79,18 @@ func TestUpsertSupplierInfo(t *testing.T) {
 	})
 
 	baseService := &service.Service{
-		DB:                                db,
-		RawRepository:                     repository.NewRawRepository(db),
-		SupplierRepository:                repository.NewSupplierRepository(db),
-		SupplierContactRepository:         repository.NewSupplierContactRepository(db),
-		SupplierContactCategoryRepository: repository.NewSupplierContactCategoryRepository(db),
-		SupplierProvider:                  provider.NewSupplierProvider(nil, flagSupClient, db),
+		DB:                                ts.DB,
+		RawRepository:                     repository.NewRawRepository(ts.DB),
+		SupplierRepository:                repository.NewSupplierRepository(ts.DB),
+		SupplierContactRepository:         repository.NewSupplierContactRepository(ts.DB),
+		SupplierContactCategoryRepository: repository.NewSupplierContactCategoryRepository(ts.DB),
+		SupplierProvider:                  provider.NewSupplierProvider(nil, flagSupClient, ts.DB),
 		FlagSupClient:                     flagSupClient,
 		IdentityClient:                    identityClient,
 		AuditLogClient:                    auditlogClient,
 	}
 
-	val := validator.NewValidator(db)
+	val := validator.NewValidator(ts.DB)
 	trans := transformer.NewTransformer()
 
 	baseServer := &rpcimpl.Server{
@@ -121,7 +109,6 @@ func TestUpsertSupplierInfo(t *testing.T) {
 			logger.Error(err, "could not close connection")
 		}
 		server.Stop()
-		ts.tearDown()
 		monkey.UnpatchAll()
 	}()
 }
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"testing"
 	"time"
 
@@ -17,54 +16,39 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
-	"go.tekoapis.com/tekone/library/integrationtest"
+	"go.tekoapis.com/tekone/library/log"
 )
 
 type getDailyForecastSuite struct {
 	tests.TestSuite
 	faker *faker.Faker
 	*gorm.DB
+	template *gorm.DB
+	svsLog   log.LogRPlus
 }
 
 func TestGetDailyForecast(t *testing.T) {
 	ts := &getDailyForecastSuite{}
-	db, svsLog := ts.InitIntegrationTestMain(t, gormDb, logService)
-	defer func(db *gorm.DB) {
-		ts.tearDown(db)
-	}(db)
+	ts.template, ts.svsLog = ts.InitIntegrationTestMain(t, gormDb, logService)
+	suite.Run(t, ts)
+}
+
+// SetupTest gives every test its own copy of the database and a server reading it.
+func (ts *getDailyForecastSuite) SetupTest() {
+	db := ts.IsolatedDB(ts.T(), ts.template)
 
 	svc := &service.Service{
 		SiteSkuDailyForecastRepository:   repository.NewSiteSkuDailyForecastRepository(db),
 		SiteGroupDailyForecastRepository: repository.NewSiteGroupDailyForecastRepository(db),
 		SiteSkuDemandRepository:          repository.NewSiteSkuDemandRepository(db),
 		SiteGroupDemandRepository:        repository.NewSiteGroupDemandRepository(db),
-		Log:                              svsLog,
+		Log:                              ts.svsLog,
 		DB:                               db,
 	}
-
-	conn, server := ts.InitServerAndClient(t, svc)
+	ts.InitServerAndClient(ts.T(), svc)
 
 	ts.faker = &faker.Faker{DB: db}
 	ts.DB = db
-	ts.tearDown(db)
-	ts.Context = context.Background()
-	suite.Run(t, ts)
-	defer func() {
-		err := conn.Close()
-		if err != nil {
-			svsLog.Error(err, "could not close connection")
-		}
-		server.Stop()
-	}()
-}
-
-func (ts *getDailyForecastSuite) tearDown(db *gorm.DB) {
-	integrationtest.TruncateDatabases(db,
-		model.SiteSkuDailyForecast{}.TableName(),
-		model.SiteGroupDailyForecast{}.TableName(),
-		model.SiteSkuDemand{}.TableName(),
-		model.SiteGroupDemand{}.TableName(),
-	)
 }
 
 func (ts *getDailyForecastSuite) assertSuccess(req *api.GetDailyForecastRequest, want string) {
@@ -75,7 +59,6 @@ func (ts *getDailyForecastSuite) assertSuccess(req *api.GetDailyForecastRequest,
 }
 
 func (ts *getDailyForecastSuite) TestSuccess_WithIsSkuDemand() {
-	defer ts.tearDown(ts.DB)
 	ts.faker.SiteSkuDemand(&model.SiteSkuDemand{
 		Id:          1,
 		SellerId:    1,
@@ -109,7 +92,6 @@ func (ts *getDailyForecastSuite) TestSuccess_WithIsSkuDemand() {
 }
 
 func (ts *getDailyForecastSuite) TestSuccess_WithoutIsSkuDemand() {
-	defer ts.tearDown(ts.DB)
 	ts.faker.SiteGroupDemand(&model.SiteGroupDemand{
 		Id:            1,
 		SellerId:      1,
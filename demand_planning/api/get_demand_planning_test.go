@@ -18,21 +18,26 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/errorz"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
-	"go.tekoapis.com/tekone/library/integrationtest"
+	"go.tekoapis.com/tekone/library/log"
 )
 
 type getDemandPlanningSuite struct {
 	tests.TestSuite
 	faker *faker.Faker
 	*gorm.DB
+	template *gorm.DB
+	svsLog   log.LogRPlus
 }
 
 func TestGetDemandPlanning(t *testing.T) {
 	ts := &getDemandPlanningSuite{}
-	db, svsLog := ts.InitIntegrationTestMain(t, gormDb, logService)
-	defer func(db *gorm.DB) {
-		ts.tearDown(db)
-	}(db)
+	ts.template, ts.svsLog = ts.InitIntegrationTestMain(t, gormDb, logService)
+	suite.Run(t, ts)
+}
+
+// SetupTest gives every test its own copy of the database and a server reading it.
+func (ts *getDemandPlanningSuite) SetupTest() {
+	db := ts.IsolatedDB(ts.T(), ts.template)
 
 	svc := &service.Service{
 		SiteSkuDemandRepository:                repository.NewSiteSkuDemandRepository(db),
@@ -41,33 +46,12 @@ func TestGetDemandPlanning(t *testing.T) {
 		MonthlySkuCategoryMappingRepository:    repository.NewMonthlySkuCategoryMappingRepository(db),
 		MonthlySkuSegmentPathMappingRepository: repository.NewMonthlySkuSegmentPathMappingRepository(db),
 		MonthlyVariantAttributeRepository:      repository.NewMonthlyVariantAttributeRepository(db),
-		Log:                                    svsLog,
+		Log:                                    ts.svsLog,
 	}
-
-	conn, server := ts.InitServerAndClient(t, svc)
+	ts.InitServerAndClient(ts.T(), svc)
 
 	ts.faker = &faker.Faker{DB: db}
 	ts.DB = db
-
-	suite.Run(t, ts)
-	defer func() {
-		err := conn.Close()
-		if err != nil {
-			svsLog.Error(err, "could not close connection")
-		}
-		server.Stop()
-	}()
-}
-
-func (ts *getDemandPlanningSuite) tearDown(db *gorm.DB) {
-	integrationtest.TruncateDatabases(db,
-		model.SiteSkuDemand{}.TableName(),
-		model.SiteGroupDemand{}.TableName(),
-		model.MonthlyCategory{}.TableName(),
-		model.MonthlySkuCategoryMapping{}.TableName(),
-		model.MonthlySkuSegmentPathMapping{}.TableName(),
-		model.MonthlyVariantAttribute{}.TableName(),
-	)
 }
 
 func (ts *getDemandPlanningSuite) assertSuccess(req *api.GetDemandPlanningRequest, want *api.GetDemandPlanningResponse) {
@@ -176,7 +160,6 @@ func (ts *getDemandPlanningSuite) TestSuccess_WithIsLatestGroup_True_And_GroupBy
 	}
 
 	ts.assertSuccess(req, want)
-	ts.tearDown(ts.DB)
 }
 
 // case with isLatestGroupTrue
@@ -309,7 +292,6 @@ func (ts *getDemandPlanningSuite) TestSuccess_WithIsLatestGroup_True_And_GroupBy
 	}
 
 	ts.assertSuccess(req, want)
-	ts.tearDown(ts.DB)
 }
 
 func (ts *getDemandPlanningSuite) TestSuccess_WithIsLatestGroupFalse_WithFilterGroupKey() {
@@ -407,7 +389,6 @@ func (ts *getDemandPlanningSuite) TestSuccess_WithIsLatestGroupFalse_WithFilterG
 	}
 
 	ts.assertSuccess(req, want)
-	ts.tearDown(ts.DB)
 }
 
 func (ts *getDemandPlanningSuite) TestSuccess_WithIsLatestGroupFalse_WithFilterCategoryId() {
@@ -544,5 +525,4 @@ func (ts *getDemandPlanningSuite) TestSuccess_WithIsLatestGroupFalse_WithFilterC
 	}
 
 	ts.assertSuccess(req, want)
-	ts.tearDown(ts.DB)
 }
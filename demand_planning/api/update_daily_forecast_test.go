@@ -22,7 +22,8 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/service"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
-	"go.tekoapis.com/tekone/library/integrationtest"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+	"go.tekoapis.com/tekone/library/log"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
 
@@ -30,6 +31,8 @@ type updateDailyForecastSuite struct {
 	tests.TestSuite
 	faker *faker.Faker
 	*gorm.DB
+	template  *gorm.DB
+	svsLog    log.LogRPlus
 	fixedTime time.Time
 	mapFlag   map[string]bool
 }
@@ -43,11 +46,21 @@ type updateDailForecastObj struct {
 
 func TestUpdateDailyForecast(t *testing.T) {
 	ts := &updateDailyForecastSuite{}
-	db, svsLog := ts.InitIntegrationTestMain(t, gormDb, logService)
-	ts.tearDown(db)
+	ts.template, ts.svsLog = ts.InitIntegrationTestMain(t, gormDb, logService)
 	monkey.UnpatchAll()
 
 	ts.mapFlag = map[string]bool{}
+	ts.fixedTime = time.Date(2022, time.January, 9, 1, 0, 0, 0, time.UTC)
+
+	monkey.Patch(time.Now, func() time.Time { return ts.fixedTime })
+	defer monkey.UnpatchAll()
+
+	suite.Run(t, ts)
+}
+
+// SetupTest gives every test its own copy of the database and a server reading it.
+func (ts *updateDailyForecastSuite) SetupTest() {
+	db := ts.IsolatedDB(ts.T(), ts.template)
 
 	flagsupClient := &mockFlagsup.ClientAdapter{}
 	flagsupClient.Mock.On("IsEnabled", mock.Anything, mock.Anything, mock.Anything).
@@ -62,44 +75,16 @@ func TestUpdateDailyForecast(t *testing.T) {
 		SiteSkuDemandRepository:          repository.NewSiteSkuDemandRepository(db),
 		SiteGroupDemandRepository:        repository.NewSiteGroupDemandRepository(db),
 		ScheduleJobProvider:              scheduleJobProvider,
-		Log:                              svsLog,
+		Log:                              ts.svsLog,
 		DB:                               db,
 	}
-	conn, server := ts.InitServerAndClient(t, svc)
+	ts.InitServerAndClient(ts.T(), svc)
 
 	ts.faker = &faker.Faker{DB: db}
 	ts.DB = db
-	ts.fixedTime = time.Date(2022, time.January, 9, 1, 0, 0, 0, time.UTC)
-
-	monkey.Patch(time.Now, func() time.Time { return ts.fixedTime })
-
-	defer func() {
-		err := conn.Close()
-		if err != nil {
-			svsLog.Error(err, "could not close connection")
-		}
-		server.Stop()
-		ts.tearDown(db)
-		monkey.UnpatchAll()
-	}()
-
-	suite.Run(t, ts)
-}
-
-func (ts *updateDailyForecastSuite) tearDown(db *gorm.DB) {
-	integrationtest.TruncateDatabases(db,
-		model.SiteSkuDailyForecast{}.TableName(),
-		model.SiteSkuDemand{}.TableName(),
-		model.SiteGroupDailyForecast{}.TableName(),
-		model.SiteGroupDemand{}.TableName(),
-		model.ScheduleJob{}.TableName(),
-	)
 }
 
 func (ts *updateDailyForecastSuite) assert(req *api.UpdateDailyForecastRequest, wantFile string) {
-	defer func() {
-		ts.tearDown(ts.DB)
-	}()
 	res, err := ts.DemandPlanningClient.UpdateDailyForecast(ts.Context, req)
 	g := goldie.New(ts.T())
 	prefix := "update_daily_forecast"
@@ -111,7 +96,6 @@ func (ts *updateDailyForecastSuite) assert(req *api.UpdateDailyForecastRequest,
 		return
 	}
 	var wantData updateDailForecastObj
-	res.TraceId = ""
 	wantData.Resp = res
 
 	var siteSkuDemands []*model.SiteSkuDemand
@@ -148,7 +132,8 @@ func (ts *updateDailyForecastSuite) assert(req *api.UpdateDailyForecastRequest,
 	}
 	wantData.ScheduleJobs = scheduleJobs
 
-	g.AssertJson(ts.T(), fmt.Sprintf("%s/%s", prefix, wantFile), wantData)
+	harness.GoldenNormalizer{Fields: map[string]string{"Resp.trace_id": harness.AnyValue}}.
+		AssertJson(ts.T(), g, fmt.Sprintf("%s/%s", prefix, wantFile), wantData)
 }
 
 func (ts *updateDailyForecastSuite) Test200_happyCase_siteSkuDemand() {
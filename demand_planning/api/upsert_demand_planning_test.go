@@ -22,7 +22,8 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
-	"go.tekoapis.com/tekone/library/integrationtest"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+	"go.tekoapis.com/tekone/library/log"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
 
@@ -30,6 +31,8 @@ type upsertDemandPlanningSuite struct {
 	tests.TestSuite
 	faker *faker.Faker
 	*gorm.DB
+	template      *gorm.DB
+	svsLog        log.LogRPlus
 	fixedTime     time.Time
 	nextFixedTime time.Time
 	prevFixedTime time.Time
@@ -45,11 +48,23 @@ type upsertDemandPlanning struct {
 
 func TestUpsertDemandPlanning_CaseImport(t *testing.T) {
 	ts := &upsertDemandPlanningSuite{}
-	db, svsLog := ts.InitIntegrationTestMain(t, gormDb, logService)
-	ts.tearDown(db)
+	ts.template, ts.svsLog = ts.InitIntegrationTestMain(t, gormDb, logService)
 	monkey.UnpatchAll()
 
 	ts.mapFlag = map[string]bool{}
+	ts.fixedTime = time.Date(2022, time.February, 9, 1, 0, 0, 0, time.UTC)
+	ts.nextFixedTime = time.Date(2022, time.March, 10, 1, 0, 0, 0, time.UTC)
+	ts.prevFixedTime = time.Date(2022, time.January, 8, 1, 0, 0, 0, time.UTC)
+
+	monkey.Patch(time.Now, func() time.Time { return ts.fixedTime })
+	defer monkey.UnpatchAll()
+
+	suite.Run(t, ts)
+}
+
+// SetupTest gives every test its own copy of the database and a server reading it.
+func (ts *upsertDemandPlanningSuite) SetupTest() {
+	db := ts.IsolatedDB(ts.T(), ts.template)
 
 	flagsupClient := &mockFlagsup.ClientAdapter{}
 	flagsupClient.Mock.On("IsEnabled", mock.Anything, mock.Anything, mock.Anything).
@@ -64,46 +79,16 @@ func TestUpsertDemandPlanning_CaseImport(t *testing.T) {
 		MonthlyVariantAttributeRepository: repository.NewMonthlyVariantAttributeRepository(db),
 		OriginalBudgetRepository:          repository.NewOriginalBudgetRepository(db),
 		ScheduleJobProvider:               scheduleJobProvider,
-		Log:                               svsLog,
+		Log:                               ts.svsLog,
 		DB:                                db,
 	}
-	conn, server := ts.InitServerAndClient(t, svc)
+	ts.InitServerAndClient(ts.T(), svc)
 
 	ts.faker = &faker.Faker{DB: db}
 	ts.DB = db
-	ts.fixedTime = time.Date(2022, time.February, 9, 1, 0, 0, 0, time.UTC)
-	ts.nextFixedTime = time.Date(2022, time.March, 10, 1, 0, 0, 0, time.UTC)
-	ts.prevFixedTime = time.Date(2022, time.January, 8, 1, 0, 0, 0, time.UTC)
-
-	monkey.Patch(time.Now, func() time.Time { return ts.fixedTime })
-
-	defer func() {
-		err := conn.Close()
-		if err != nil {
-			svsLog.Error(err, "could not close connection")
-		}
-		server.Stop()
-		ts.tearDown(db)
-		monkey.UnpatchAll()
-	}()
-
-	suite.Run(t, ts)
-}
-
-func (ts *upsertDemandPlanningSuite) tearDown(db *gorm.DB) {
-	integrationtest.TruncateDatabases(db,
-		model.SiteGroupDemand{}.TableName(),
-		model.OriginalBudget{}.TableName(),
-		model.MonthlyCategory{}.TableName(),
-		model.MonthlyVariantAttribute{}.TableName(),
-		model.ScheduleJob{}.TableName(),
-	)
 }
 
 func (ts *upsertDemandPlanningSuite) assert(req *api.UpsertDemandPlanningRequest, wantFile string) {
-	defer func() {
-		ts.tearDown(ts.DB)
-	}()
 	res, err := ts.DemandPlanningClient.UpsertDemandPlanning(ts.Context, req)
 	g := goldie.New(ts.T())
 	prefix := "upsert_demand_planning"
@@ -115,7 +100,6 @@ func (ts *upsertDemandPlanningSuite) assert(req *api.UpsertDemandPlanningRequest
 		return
 	}
 	var wantData upsertDemandPlanning
-	res.TraceId = ""
 	wantData.Resp = res
 
 	var originalBudget []*model.OriginalBudget
@@ -152,7 +136,8 @@ func (ts *upsertDemandPlanningSuite) assert(req *api.UpsertDemandPlanningRequest
 	}
 	wantData.ScheduleJobs = scheduleJobs
 
-	g.AssertJson(ts.T(), fmt.Sprintf("%s/%s", prefix, wantFile), wantData)
+	harness.GoldenNormalizer{Fields: map[string]string{"Resp.trace_id": harness.AnyValue}}.
+		AssertJson(ts.T(), g, fmt.Sprintf("%s/%s", prefix, wantFile), wantData)
 }
 
 func (ts *upsertDemandPlanningSuite) Test200_HappyCase_ImportBudget() {
@@ -0,0 +1,25 @@
+package tests
+
+import (
+	"reflect"
+
+	"github.com/gogo/protobuf/types"
+
+	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/adapter/fileservice"
+	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/helper"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+	"go.tekoapis.com/tekone/library/test/monkey"
+)
+
+// PatchFileService makes the file service adapter upload to files instead of the file service,
+// the URL it returns opens the upload with files.OpenExcel. monkey.UnpatchAll removes the patch.
+func PatchFileService(files *harness.FakeFileService) {
+	monkey.PatchInstanceMethod(reflect.TypeOf(&fileservice.FilesClientImpl{}), "UploadDoc",
+		func(c *fileservice.FilesClientImpl, filePath string) (*types.StringValue, error) {
+			url, err := files.UploadFile(filePath, "")
+			if err != nil {
+				return nil, err
+			}
+			return helper.StringToProtoString(url), nil
+		})
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm/schema"
+
+	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/model"
+	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+)
+
+type fixtureFaker struct {
+	model schema.Tabler
+	fake  func(f *faker.Faker, row interface{})
+}
+
+const fixturesDir = "fixtures"
+
+// fixtureFakers fake the rows of the fixtures table by table, in this order
+var fixtureFakers = []fixtureFaker{
+	{model.SellerConfig{}, func(f *faker.Faker, row interface{}) {
+		f.SellerConfig(row.(*model.SellerConfig), true)
+	}},
+	{model.MonthlyCategory{}, func(f *faker.Faker, row interface{}) {
+		f.MonthlyCategory(row.(*model.MonthlyCategory), true)
+	}},
+	{model.MonthlySegment{}, func(f *faker.Faker, row interface{}) {
+		f.MonthlySegment(row.(*model.MonthlySegment), true)
+	}},
+	{model.MonthlySkuCategoryMapping{}, func(f *faker.Faker, row interface{}) {
+		f.MonthlySkuCategoryMapping(row.(*model.MonthlySkuCategoryMapping), true)
+	}},
+	{model.MonthlySkuSegmentPathMapping{}, func(f *faker.Faker, row interface{}) {
+		f.MonthlySkuSegmentPathMapping(row.(*model.MonthlySkuSegmentPathMapping), true)
+	}},
+	{model.MonthlyVariantAttribute{}, func(f *faker.Faker, row interface{}) {
+		f.MonthlyVariantAttribute(row.(*model.MonthlyVariantAttribute), true)
+	}},
+	{model.OriginalBudget{}, func(f *faker.Faker, row interface{}) {
+		f.OriginalBudget(row.(*model.OriginalBudget), true)
+	}},
+	{model.SiteSkuDemand{}, func(f *faker.Faker, row interface{}) {
+		f.SiteSkuDemand(row.(*model.SiteSkuDemand), true)
+	}},
+	{model.SiteSkuDailyForecast{}, func(f *faker.Faker, row interface{}) {
+		f.SiteSkuDailyForecast(row.(*model.SiteSkuDailyForecast), true)
+	}},
+	{model.SiteGroupDemand{}, func(f *faker.Faker, row interface{}) {
+		f.SiteGroupDemand(row.(*model.SiteGroupDemand), true)
+	}},
+	{model.SiteGroupDailyForecast{}, func(f *faker.Faker, row interface{}) {
+		f.SiteGroupDailyForecast(row.(*model.SiteGroupDailyForecast), true)
+	}},
+}
+
+// SeedFixtures fakes the rows of the fixture files, see harness.Fixtures for their format. The
+// files are named relative to the fixtures directory shared by the test packages and vars holds
+// the ${name} variables, such as the month of year of the test.
+func SeedFixtures(t testing.TB, f *faker.Faker, vars map[string]interface{}, files ...string) {
+	t.Helper()
+	dir, err := findFixturesDir()
+	if err != nil {
+		t.Fatal("Can't find the fixtures", err)
+	}
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, filepath.Join(dir, file))
+	}
+	fixtures, err := harness.LoadFixtures(vars, paths...)
+	if err != nil {
+		t.Fatal("Can't load the fixtures", err)
+	}
+	faked := make(map[string]bool)
+	for _, table := range fixtureFakers {
+		rows, err := fixtures.Rows(f.DB, table.model)
+		if err != nil {
+			t.Fatal("Can't read the fixtures", err)
+		}
+		for _, row := range rows {
+			table.fake(f, row)
+		}
+		faked[table.model.TableName()] = true
+	}
+	for _, table := range fixtures.Tables() {
+		if !faked[table] {
+			t.Fatalf("The faker can't fill table %s of the fixtures", table)
+		}
+	}
+}
+
+// findFixturesDir looks for the fixtures from the directory of the test package up, like
+// the unittest.json config.
+func findFixturesDir() (string, error) {
+	dir := fixturesDir
+	for i := 0; i < 5; i++ {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+		dir = filepath.Join("..", dir)
+	}
+	return "", fmt.Errorf("no %s directory above the test package", fixturesDir)
+}
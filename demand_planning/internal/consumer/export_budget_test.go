@@ -1,19 +1,13 @@
 package consumer
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"flag"
 	"fmt"
-	"os"
 	"reflect"
 	"testing"
 	"time"
 
-	"github.com/360EntSecGroup-Skylar/excelize/v3"
-	"github.com/gogo/protobuf/types"
-	"github.com/sebdah/goldie/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
@@ -21,7 +15,6 @@ import (
 	exportServiceApi "go.tekoapis.com/tekone/app/aggregator/export-service/api"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/config"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/adapter/export_service"
-	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/adapter/fileservice"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/adapter/whcentral"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/consumer/exports"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/model"
@@ -30,22 +23,20 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	whCentralApi "go.tekoapis.com/tekone/app/warehouse/central-service/api"
 	"go.tekoapis.com/tekone/library/integrationtest"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
 
-var isUpdate = flag.Bool("update-excel", false, "is update or not")
-
 type exportBudgetTestCase struct {
 	tests.TestSuite
-	faker         *faker.Faker
-	db            *gorm.DB
-	worker        *exports.Worker
-	mapResultFile map[string]*excelize.File
-	fixedTime     time.Time
-	sellerId      int32
-	isGenNewFile  bool
+	faker     *faker.Faker
+	db        *gorm.DB
+	worker    *exports.Worker
+	files     *harness.FakeFileService
+	fixedTime time.Time
+	sellerId  int32
 }
 
 func TestService_ExportBudget(t *testing.T) {
@@ -54,7 +45,7 @@ func TestService_ExportBudget(t *testing.T) {
 	ts.db = db
 	ts.sellerId = 3
 	ts.fixedTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
-	ts.mapResultFile = make(map[string]*excelize.File)
+	ts.files = harness.NewFakeFileService()
 	ts.faker = &faker.Faker{DB: db}
 
 	defer func() {
@@ -62,10 +53,6 @@ func TestService_ExportBudget(t *testing.T) {
 		ts.tearDown()
 	}()
 
-	if isUpdate != nil {
-		ts.isGenNewFile = *isUpdate
-	}
-
 	mockExportService := &export_service.Client{}
 	monkey.PatchInstanceMethod(reflect.TypeOf(mockExportService), "GetExportRequest",
 		func(c *export_service.Client, ctx context.Context, reqExport *exportServiceApi.GetExportRequestRequest) (*exportServiceApi.GetExportRequestResponse, error) {
@@ -83,17 +70,7 @@ func TestService_ExportBudget(t *testing.T) {
 			return &exportServiceApi.UpdateExportRequestResponse{}, nil
 		})
 
-	fileServiceClient := &fileservice.FilesClientImpl{}
-	monkey.PatchInstanceMethod(reflect.TypeOf(fileServiceClient), "UploadDoc",
-		func(c *fileservice.FilesClientImpl, filePath string) (*types.StringValue, error) {
-			fileReader, err := os.ReadFile(filePath)
-			assert.Nil(ts.T(), err)
-
-			file, err := excelize.OpenReader(bytes.NewReader(fileReader))
-			assert.Nil(ts.T(), err)
-			ts.mapResultFile[filePath] = file
-			return helper.StringToProtoString(filePath), nil
-		})
+	tests.PatchFileService(ts.files)
 
 	whCentralClient := &whcentral.ImplClient{}
 	monkey.PatchInstanceMethod(reflect.TypeOf(whCentralClient), "GetMapSiteId2SiteInfo",
@@ -154,29 +131,9 @@ func (ts *exportBudgetTestCase) assert(exportReqPayload *exportServiceApi.Payloa
 	})
 	assert.Nil(ts.T(), err)
 
-	prefixPath := "export_budget"
-	if ts.isGenNewFile {
-		ts.mapResultFile[url].SaveAs(fmt.Sprintf("testdata/%s/%s.xlsx", prefixPath, wantFile))
-	}
-	fileReader, err := os.ReadFile(fmt.Sprintf("testdata/%s/%s.xlsx", prefixPath, wantFile))
-	assert.Nil(ts.T(), err)
-	expected, err := excelize.OpenReader(bytes.NewReader(fileReader))
-	assert.Nil(ts.T(), err)
-	actual := ts.mapResultFile[url]
-	rows, err := actual.GetRows(exports.ExportBudgetSheetName)
-	assert.Nil(ts.T(), err)
-	goldie.New(ts.T()).AssertJson(ts.T(), fmt.Sprintf("%s/%s", prefixPath, wantFile), rows)
-
-	for row := 1; row <= len(rows); row++ {
-		for col := 'A'; col < 'Z'; col++ {
-			colName := fmt.Sprintf("%c", col)
-			rs1, err := helper.GetCell(colName, row, expected, exports.ExportBudgetSheetName)
-			assert.Nil(ts.T(), err)
-			rs2, err := helper.GetCell(colName, row, actual, exports.ExportBudgetSheetName)
-			assert.Nil(ts.T(), err)
-			assert.Equal(ts.T(), rs1, rs2, helper.GetAxis(colName, row))
-		}
-	}
+	actual, err := ts.files.OpenExcel(url)
+	ts.Require().NoError(err)
+	harness.WorkbookGolden{Sheets: []string{"Data"}, MergedCells: true}.Assert(ts.T(), fmt.Sprintf("export_budget/%s", wantFile), actual)
 }
 
 func (ts *exportBudgetTestCase) Test200_ReqExportMultiYear_ReturnSuccess() {
@@ -1,50 +1,41 @@
 package consumer
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
-	"flag"
 	"fmt"
-	"os"
 	"reflect"
 	"testing"
 	"time"
 
-	"github.com/360EntSecGroup-Skylar/excelize/v3"
 	"github.com/gogo/protobuf/types"
-	"github.com/sebdah/goldie/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
 
 	exportServiceApi "go.tekoapis.com/tekone/app/aggregator/export-service/api"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/config"
-	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/adapter/fileservice"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/adapter/whcentral"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/consumer/exports"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/model"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/repository"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/service"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
-	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	whCentralApi "go.tekoapis.com/tekone/app/warehouse/central-service/api"
 	"go.tekoapis.com/tekone/library/integrationtest"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
 
-var isUpdateForecast = flag.Bool("update-forecast-excel", false, "is update or not")
-
 type exportForecastTestCase struct {
 	tests.TestSuite
-	faker         *faker.Faker
-	db            *gorm.DB
-	worker        *exports.Worker
-	mapResultFile map[string]*excelize.File
-	sellerId      int32
-	isGenNewFile  bool
+	faker    *faker.Faker
+	db       *gorm.DB
+	worker   *exports.Worker
+	files    *harness.FakeFileService
+	sellerId int32
 }
 
 func TestService_ExportForecast(t *testing.T) {
@@ -52,7 +43,7 @@ func TestService_ExportForecast(t *testing.T) {
 	db, serviceLog := ts.InitIntegrationTestMain(t, gormDb, logService)
 	ts.db = db
 	ts.sellerId = 1
-	ts.mapResultFile = make(map[string]*excelize.File)
+	ts.files = harness.NewFakeFileService()
 	ts.faker = &faker.Faker{DB: db}
 
 	defer func() {
@@ -60,21 +51,7 @@ func TestService_ExportForecast(t *testing.T) {
 		ts.tearDown()
 	}()
 
-	if isUpdateForecast != nil {
-		ts.isGenNewFile = *isUpdateForecast
-	}
-
-	fileServiceClient := &fileservice.FilesClientImpl{}
-	monkey.PatchInstanceMethod(reflect.TypeOf(fileServiceClient), "UploadDoc",
-		func(c *fileservice.FilesClientImpl, filePath string) (*types.StringValue, error) {
-			fileReader, err := os.ReadFile(filePath)
-			assert.Nil(ts.T(), err)
-
-			file, err := excelize.OpenReader(bytes.NewReader(fileReader))
-			assert.Nil(ts.T(), err)
-			ts.mapResultFile[filePath] = file
-			return helper.StringToProtoString(filePath), nil
-		})
+	tests.PatchFileService(ts.files)
 
 	whCentralClient := &whcentral.ImplClient{}
 	monkey.PatchInstanceMethod(reflect.TypeOf(whCentralClient), "GetMapSiteId2SiteInfo",
@@ -136,32 +113,9 @@ func (ts *exportForecastTestCase) assert(exportReqPayload *exportServiceApi.Payl
 	})
 	assert.Nil(ts.T(), err)
 
-	prefixPath := "export_forecast"
-	if ts.isGenNewFile {
-		err := ts.mapResultFile[url].SaveAs(fmt.Sprintf("testdata/%s/%s.xlsx", prefixPath, wantFile))
-		if err != nil {
-			return
-		}
-	}
-	fileReader, err := os.ReadFile(fmt.Sprintf("testdata/%s/%s.xlsx", prefixPath, wantFile))
-	assert.Nil(ts.T(), err)
-	expected, err := excelize.OpenReader(bytes.NewReader(fileReader))
-	assert.Nil(ts.T(), err)
-	actual := ts.mapResultFile[url]
-	cols, err := actual.GetRows(exports.ExportForecastSheetName)
-	assert.Nil(ts.T(), err)
-	goldie.New(ts.T()).AssertJson(ts.T(), fmt.Sprintf("%s/%s", prefixPath, wantFile), cols)
-
-	for row := 1; row <= len(cols); row++ {
-		for col := 0; col < len(cols[row-1]); col++ {
-			colName := fmt.Sprintf("%c", col+'A')
-			rs1, err := helper.GetCell(colName, row, expected, exports.ExportForecastSheetName)
-			assert.Nil(ts.T(), err)
-			rs2, err := helper.GetCell(colName, row, actual, exports.ExportForecastSheetName)
-			assert.Nil(ts.T(), err)
-			assert.Equal(ts.T(), rs1, rs2, helper.GetAxis(colName, row))
-		}
-	}
+	actual, err := ts.files.OpenExcel(url)
+	ts.Require().NoError(err)
+	harness.WorkbookGolden{Sheets: []string{"Data", "convertV3"}, MergedCells: true}.Assert(ts.T(), fmt.Sprintf("export_forecast/%s", wantFile), actual)
 }
 
 func (ts *exportForecastTestCase) initDefaultData() {
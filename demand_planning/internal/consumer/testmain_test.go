@@ -5,37 +5,34 @@ import (
 	"os"
 	"testing"
 
+	"github.com/testcontainers/testcontainers-go"
 	"gorm.io/gorm"
 
-	"go.tekoapis.com/tekone/library/integrationtest"
+	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	"go.tekoapis.com/tekone/library/log"
 )
 
 var (
 	gormDb     *gorm.DB
 	logService log.LogRPlus
-	testData   *integrationtest.TestMainDataStruct
 )
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
-	var err error
-	var exitVal int
-	testData, err = integrationtest.SetUpTestMain(ctx, m, integrationtest.IntegrationTestOptions{
+	var testContainer testcontainers.Container
+	var exit bool
+	testContainer, gormDb, logService, exit = tests.SetUpTestMain(m, ctx, harness.DatabaseOptions{
 		IsDisableCheckForeignKey: true,
 		IsDisableUniqueKey:       true,
-		DatabaseType:             integrationtest.DatabaseTypeMySql,
 	})
-	gormDb, logService = testData.Db, testData.LogService
-	defer func() {
-		testData.DeferFunction()
-		if err != nil {
-			panic(err)
-		}
-		os.Exit(exitVal)
-	}()
+	if exit {
+		return
+	}
+	exitVal := m.Run()
+	err := testContainer.Terminate(ctx)
 	if err != nil {
 		return
 	}
-	exitVal = m.Run()
+	os.Exit(exitVal)
 }
@@ -23,6 +23,7 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	"go.tekoapis.com/tekone/library/integrationtest"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
@@ -45,7 +46,7 @@ func TestJob_EnrichCatalogData(t *testing.T) {
 	ts.faker = &faker.Faker{DB: db}
 
 	ts.tearDown()
-	ts.setUp()
+	ts.setUp(t)
 
 	defer func() {
 		ts.tearDown()
@@ -472,69 +473,11 @@ func (ts *enrichCatalogDataTestSuite) tearDown() {
 	)
 }
 
-func (ts *enrichCatalogDataTestSuite) setUp() {
-	// Init Seller Config
-	ts.faker.SellerConfig(&model.SellerConfig{
-		SellerId:          1,
-		UseDemandPlanning: true,
-	}, true)
-	ts.faker.SellerConfig(&model.SellerConfig{
-		SellerId:          2,
-		UseDemandPlanning: false,
-	}, true)
-	// Init MonthlyCategory
-	ts.faker.MonthlyCategory(&model.MonthlyCategory{
-		SellerId:    1,
-		CategoryId:  1,
-		Code:        "CATE1",
-		Name:        "Category 1",
-		MonthOfYear: "2023-09",
-	}, true)
-	ts.faker.MonthlyCategory(&model.MonthlyCategory{
-		SellerId:    1,
-		CategoryId:  2,
-		Code:        "CATE2",
-		Name:        "Category 2",
-		MonthOfYear: "2023-09",
-	}, true)
-	// Init Monthly Segment
-	ts.faker.MonthlySegment(&model.MonthlySegment{
-		SellerId:    1,
-		CategoryId:  7,
-		AttributeId: 3,
-		Level:       1,
-		MonthOfYear: "2023-09",
-	}, true)
-	ts.faker.MonthlySegment(&model.MonthlySegment{
-		SellerId:    1,
-		CategoryId:  7,
-		AttributeId: 1,
-		Level:       2,
-		MonthOfYear: "2023-09",
-	}, true)
-	ts.faker.MonthlySegment(&model.MonthlySegment{
-		SellerId:    1,
-		CategoryId:  7,
-		AttributeId: 2,
-		Level:       3,
-		MonthOfYear: "2023-09",
-	}, true)
-	// Init MonthlySkuCategoryMapping
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku10",
-		SellerSku:             "sellerSku10",
-		Name:                  "sku name 10",
-		LatestChildCategoryId: 10,
-		MonthOfYear:           "2023-10",
-	}, true)
-	// Init MonthlySkuSegmentPathMapping
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:    1,
-		Sku:         "sku10",
-		SegmentPath: "1/2/3",
-		MonthOfYear: "2023-10",
-	}, true)
+func (ts *enrichCatalogDataTestSuite) setUp(t *testing.T) {
+	tests.SeedFixtures(t, ts.faker, map[string]interface{}{
+		"monthOfYear": ts.fixedTime.Format("2006-01"),
+		"lastMonth":   ts.fixedTime.AddDate(0, -1, 0).Format("2006-01"),
+	}, "enrich_catalog_data.yaml")
 }
 
 func (ts *enrichCatalogDataTestSuite) Test200_EnrichCatalogDataSuccessful() {
@@ -543,59 +486,16 @@ func (ts *enrichCatalogDataTestSuite) Test200_EnrichCatalogDataSuccessful() {
 	err := ts.job.Run(context.Background())
 	assert.Nil(ts.T(), err)
 
-	var monthlyCategories []*model.MonthlyCategory
-	_ = ts.db.Table(model.MonthlyCategory{}.TableName()).Find(&monthlyCategories).Error
-	for _, data := range monthlyCategories {
-		data.Id = 0
-		data.CreatedAt = ts.fixedTime
-		data.UpdatedAt = ts.fixedTime
-	}
-
-	var monthlySegments []*model.MonthlySegment
-	_ = ts.db.Table(model.MonthlySegment{}.TableName()).Order("month_of_year, category_id, attribute_id").Find(&monthlySegments).Error
-	for _, data := range monthlySegments {
-		data.Id = 0
-		data.CreatedAt = ts.fixedTime
-		data.UpdatedAt = ts.fixedTime
-	}
-
-	var monthlySkuCategoryMappings []*model.MonthlySkuCategoryMapping
-	_ = ts.db.Table(model.MonthlySkuCategoryMapping{}.TableName()).Find(&monthlySkuCategoryMappings).Error
-	for _, data := range monthlySkuCategoryMappings {
-		data.Id = 0
-		data.CreatedAt = ts.fixedTime
-		data.UpdatedAt = ts.fixedTime
-	}
-
-	var monthlyVariantAttributes []*model.MonthlyVariantAttribute
-	_ = ts.db.Table(model.MonthlyVariantAttribute{}.TableName()).Find(&monthlyVariantAttributes).Error
-	for _, data := range monthlyVariantAttributes {
-		data.Id = 0
-		data.CreatedAt = ts.fixedTime
-		data.UpdatedAt = ts.fixedTime
-	}
-
-	var monthlySkuSegmentPathMappings []*model.MonthlySkuSegmentPathMapping
-	_ = ts.db.Table(model.MonthlySkuSegmentPathMapping{}.TableName()).Find(&monthlySkuSegmentPathMappings).Error
-	for _, data := range monthlySkuSegmentPathMappings {
-		data.Id = 0
-		data.CreatedAt = ts.fixedTime
-		data.UpdatedAt = ts.fixedTime
-	}
-
-	enrichData := struct {
-		MonthlyCategories             []*model.MonthlyCategory
-		MonthlySegments               []*model.MonthlySegment
-		MonthlySkuCategoryMappings    []*model.MonthlySkuCategoryMapping
-		MonthlyVariantAttribute       []*model.MonthlyVariantAttribute
-		MonthlySkuSegmentPathMappings []*model.MonthlySkuSegmentPathMapping
-	}{
-		MonthlyCategories:             monthlyCategories,
-		MonthlySegments:               monthlySegments,
-		MonthlySkuCategoryMappings:    monthlySkuCategoryMappings,
-		MonthlyVariantAttribute:       monthlyVariantAttributes,
-		MonthlySkuSegmentPathMappings: monthlySkuSegmentPathMappings,
-	}
-
-	goldie.New(ts.T()).AssertJson(ts.T(), "enrich_catalog_data/happy_case_enrich_catalog_data_successful", enrichData)
+	harness.DatasetGolden{
+		Normalizer: harness.GoldenNormalizer{Fields: harness.ModelGoldenFields},
+		OrderBy: map[string]string{
+			"monthly_segment": "month_of_year, category_id, attribute_id",
+		},
+	}.Assert(ts.T(), goldie.New(ts.T()), ts.db, "enrich_catalog_data/happy_case_enrich_catalog_data_successful",
+		model.MonthlyCategory{},
+		model.MonthlySegment{},
+		model.MonthlySkuCategoryMapping{},
+		model.MonthlyVariantAttribute{},
+		model.MonthlySkuSegmentPathMapping{},
+	)
 }
@@ -22,6 +22,7 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	"go.tekoapis.com/tekone/library/integrationtest"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
@@ -35,9 +36,13 @@ type enrichDWHDataTestSuite struct {
 	downloadMinIOError error
 }
 
-type assertObj struct {
-	SiteSkuDemands   []*model.SiteSkuDemand
-	SiteGroupDemands []*model.SiteGroupDemand
+// demandsGolden orders the demands the job writes by their keys, their ids are normalized
+var demandsGolden = harness.DatasetGolden{
+	Normalizer: harness.GoldenNormalizer{Fields: harness.ModelGoldenFields},
+	OrderBy: map[string]string{
+		"site_sku_demand":   "month_of_year, sku, site_id",
+		"site_group_demand": "month_of_year, group_by, group_key, site_id",
+	},
 }
 
 func TestJob_EnrichDWHData(t *testing.T) {
@@ -118,220 +123,13 @@ func (ts *enrichDWHDataTestSuite) tearDown() {
 }
 
 func (ts *enrichDWHDataTestSuite) setUp() {
-	ts.faker.SellerConfig(&model.SellerConfig{
-		SellerId:          1,
-		UseDemandPlanning: true,
-	}, true)
-	ts.faker.SellerConfig(&model.SellerConfig{
-		SellerId:          2,
-		UseDemandPlanning: true,
-	}, true)
-
-	ts.faker.SiteSkuDemand(&model.SiteSkuDemand{
-		SellerId:    1,
-		Sku:         "sku01",
-		SiteId:      1,
-		MonthOfYear: "2023-10",
-		Budget:      helper.Float64ToSqlFloat64(12893.23),
-	}, true)
-
-	monthOfYear := "2023-10"
-
-	ts.faker.MonthlyCategory(&model.MonthlyCategory{
-		SellerId:    1,
-		CategoryId:  1,
-		Code:        "CATE1",
-		Path:        helper.StringToSqlString("1"),
-		Name:        "Category 1",
-		MonthOfYear: monthOfYear,
-	}, true)
-	ts.faker.MonthlyCategory(&model.MonthlyCategory{
-		SellerId:    1,
-		CategoryId:  2,
-		Code:        "CATE2",
-		Path:        helper.StringToSqlString("1/2"),
-		Name:        "Category 2",
-		ParentId:    helper.Int32ToSqlInt32(1),
-		MonthOfYear: monthOfYear,
-	}, true)
-	ts.faker.MonthlyCategory(&model.MonthlyCategory{
-		SellerId:    1,
-		CategoryId:  3,
-		Code:        "CATE3",
-		Path:        helper.StringToSqlString("1/3"),
-		Name:        "Category 3",
-		ParentId:    helper.Int32ToSqlInt32(1),
-		MonthOfYear: monthOfYear,
-	}, true)
-	ts.faker.MonthlyCategory(&model.MonthlyCategory{
-		SellerId:    2,
-		CategoryId:  4,
-		Code:        "CATE4",
-		Path:        helper.StringToSqlString("0"),
-		Name:        "Category 4",
-		MonthOfYear: monthOfYear,
-	}, true)
-	ts.faker.MonthlyCategory(&model.MonthlyCategory{
-		SellerId:    2,
-		CategoryId:  5,
-		Code:        "CATE5",
-		Path:        helper.StringToSqlString("4/5"),
-		Name:        "Category 5",
-		ParentId:    helper.Int32ToSqlInt32(4),
-		MonthOfYear: monthOfYear,
-	}, true)
-
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku01",
-		SellerSku:             "sellerSku1",
-		Name:                  "sku name 1",
-		LatestChildCategoryId: 2,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku02",
-		SellerSku:             "sellerSku2",
-		Name:                  "sku name 2",
-		LatestChildCategoryId: 2,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku03",
-		SellerSku:             "sellerSku3",
-		Name:                  "sku name 3",
-		LatestChildCategoryId: 3,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku04",
-		SellerSku:             "sellerSku4",
-		Name:                  "sku name 4",
-		LatestChildCategoryId: 3,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku05",
-		SellerSku:             "sellerSku5",
-		Name:                  "sku name 5",
-		LatestChildCategoryId: 3,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku06",
-		SellerSku:             "sellerSku6",
-		Name:                  "sku name 6",
-		LatestChildCategoryId: 3,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku07",
-		SellerSku:             "sellerSku7",
-		Name:                  "sku name 7",
-		LatestChildCategoryId: 3,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              1,
-		Sku:                   "sku08",
-		SellerSku:             "sellerSku8",
-		Name:                  "sku name 8",
-		LatestChildCategoryId: 3,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              2,
-		Sku:                   "sku09",
-		SellerSku:             "sellerSku9",
-		Name:                  "sku name 9",
-		LatestChildCategoryId: 3,
-		MonthOfYear:           monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuCategoryMapping(&model.MonthlySkuCategoryMapping{
-		SellerId:              2,
-		Sku:                   "sku10",
-		SellerSku:             "sellerSku10",
-		Name:                  "sku name 10",
-		LatestChildCategoryId: 3,
-		MonthOfYear:           monthOfYear,
-	}, true)
+	tests.SeedFixtures(ts.T(), ts.faker, map[string]interface{}{
+		"monthOfYear": "2023-10",
+	}, "category_tree.yaml", "enrich_dwh_data.yaml")
+}
 
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         1,
-		Sku:              "sku01",
-		MasterCategoryId: 1,
-		SegmentPath:      "1/2",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         1,
-		Sku:              "sku02",
-		MasterCategoryId: 1,
-		SegmentPath:      "1/3",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         1,
-		Sku:              "sku03",
-		MasterCategoryId: 1,
-		SegmentPath:      "1/2",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         1,
-		Sku:              "sku04",
-		MasterCategoryId: 1,
-		SegmentPath:      "1/3",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         1,
-		Sku:              "sku05",
-		MasterCategoryId: 1,
-		SegmentPath:      "1/3",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         1,
-		Sku:              "sku06",
-		MasterCategoryId: 1,
-		SegmentPath:      "1/3",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         1,
-		Sku:              "sku07",
-		MasterCategoryId: 1,
-		SegmentPath:      "1/3",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         1,
-		Sku:              "sku08",
-		MasterCategoryId: 1,
-		SegmentPath:      "1/3",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         2,
-		Sku:              "sku09",
-		MasterCategoryId: 4,
-		SegmentPath:      "4/5",
-		MonthOfYear:      monthOfYear,
-	}, true)
-	ts.faker.MonthlySkuSegmentPathMapping(&model.MonthlySkuSegmentPathMapping{
-		SellerId:         2,
-		Sku:              "sku10",
-		MasterCategoryId: 4,
-		SegmentPath:      "4/5",
-		MonthOfYear:      monthOfYear,
-	}, true)
+func (ts *enrichDWHDataTestSuite) assertDemands(wantFile string) {
+	demandsGolden.Assert(ts.T(), goldie.New(ts.T()), ts.db, wantFile, model.SiteSkuDemand{}, model.SiteGroupDemand{})
 }
 
 func (ts *enrichDWHDataTestSuite) Test_EnrichDWHDataJobImpl_RunBySeller() {
@@ -339,33 +137,7 @@ func (ts *enrichDWHDataTestSuite) Test_EnrichDWHDataJobImpl_RunBySeller() {
 	defer ts.tearDown()
 	ts.job.Run(context.Background())
 
-	var siteSkuDemands []*model.SiteSkuDemand
-	err := ts.db.Table(model.SiteSkuDemand{}.TableName()).Order("month_of_year, sku, site_id").Find(&siteSkuDemands).Error
-	if err != nil {
-		ts.T().Fatal(err)
-	}
-	for _, item := range siteSkuDemands {
-		item.Id = 0
-		item.CreatedAt = ts.fixedTime
-		item.UpdatedAt = ts.fixedTime
-	}
-
-	var siteGroupDemands []*model.SiteGroupDemand
-	ts.db.Debug()
-	err = ts.db.Table(model.SiteGroupDemand{}.TableName()).Order("month_of_year, group_by, group_key, site_id").Find(&siteGroupDemands).Error
-	if err != nil {
-		ts.T().Fatal(err)
-	}
-	for _, item := range siteGroupDemands {
-		item.Id = 0
-		item.CreatedAt = ts.fixedTime
-		item.UpdatedAt = ts.fixedTime
-	}
-
-	goldie.New(ts.T()).AssertJson(ts.T(), "enrich_dwh_data/happy_case_enrich_dwh_data_successful", assertObj{
-		SiteSkuDemands:   siteSkuDemands,
-		SiteGroupDemands: siteGroupDemands,
-	})
+	ts.assertDemands("enrich_dwh_data/happy_case_enrich_dwh_data_successful")
 }
 
 func (ts *enrichDWHDataTestSuite) Test_EnrichDWHDataJobImpl_RunBySeller_EnrichDataFromMinIOFailed() {
@@ -377,30 +149,5 @@ func (ts *enrichDWHDataTestSuite) Test_EnrichDWHDataJobImpl_RunBySeller_EnrichDa
 	}()
 	ts.job.Run(context.Background())
 
-	var siteSkuDemands []*model.SiteSkuDemand
-	err := ts.db.Table(model.SiteSkuDemand{}.TableName()).Order("month_of_year, sku, site_id").Find(&siteSkuDemands).Error
-	if err != nil {
-		ts.T().Fatal(err)
-	}
-	for _, item := range siteSkuDemands {
-		item.Id = 0
-		item.CreatedAt = ts.fixedTime
-		item.UpdatedAt = ts.fixedTime
-	}
-
-	var siteGroupDemands []*model.SiteGroupDemand
-	err = ts.db.Order("month_of_year, group_by, group_key, site_id").Find(&siteGroupDemands).Error
-	if err != nil {
-		ts.T().Fatal(err)
-	}
-	for _, item := range siteGroupDemands {
-		item.Id = 0
-		item.CreatedAt = ts.fixedTime
-		item.UpdatedAt = ts.fixedTime
-	}
-
-	goldie.New(ts.T()).AssertJson(ts.T(), "enrich_dwh_data/happy_case_enrich_data_from_minio_failed", assertObj{
-		SiteSkuDemands:   siteSkuDemands,
-		SiteGroupDemands: siteGroupDemands,
-	})
+	ts.assertDemands("enrich_dwh_data/happy_case_enrich_data_from_minio_failed")
 }
@@ -3,7 +3,6 @@ package job
 import (
 	"bytes"
 	"context"
-	"fmt"
 	"os"
 	"reflect"
 	"testing"
@@ -26,15 +25,14 @@ import (
 	mockCatalogGrpc "go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/mocks/adapter/catalog_grpc"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/model"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/repository"
-	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/mocks/faker"
 	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	"go.tekoapis.com/tekone/library/integrationtest"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
 
 type migrateSegmentTest struct {
 	tests.TestSuite
-	faker      *faker.Faker
 	db         *gorm.DB
 	fixedTime  time.Time
 	sellerId   int32
@@ -49,7 +47,6 @@ func TestMigrateSegment(t *testing.T) {
 	ts.sellerId = 1
 	db, _ := ts.InitIntegrationTestMain(t, gormDb, logService)
 	ts.db = db
-	ts.faker = &faker.Faker{DB: db}
 	ts.fixedTime = time.Date(2022, time.November, 1, 1, 0, 0, 0, time.UTC)
 	cfg, err := config.Load()
 	if err != nil {
@@ -132,14 +129,9 @@ func (ts *migrateSegmentTest) tearDown() {
 func (ts *migrateSegmentTest) assertMonthlySegment(wantFile string) {
 	defer ts.tearDown()
 
-	var monthlySegments []*model.MonthlySegment
-	err := ts.db.Table(model.MonthlySegment{}.TableName()).Find(&monthlySegments).Order("seller_id asc, category_id asc, attribute_id asc, month_of_year asc").Error
-	assert.Nil(ts.T(), err)
-	for _, data := range monthlySegments {
-		data.CreatedAt = ts.fixedTime
-		data.UpdatedAt = ts.fixedTime
-	}
-	goldie.New(ts.T()).AssertJson(ts.T(), fmt.Sprintf("migrate_segment/%s", wantFile), monthlySegments)
+	harness.DatasetGolden{
+		Normalizer: harness.GoldenNormalizer{Fields: harness.ModelGoldenFields},
+	}.Assert(ts.T(), goldie.New(ts.T()), ts.db, "migrate_segment/"+wantFile, model.MonthlySegment{})
 }
 
 func (ts *migrateSegmentTest) TestHappyCase() {
@@ -165,13 +157,8 @@ func (ts *migrateSegmentTest) TestCase_Not_Found_Category_And_Attribute() {
 }
 
 func (ts *migrateSegmentTest) TestCase_Upsert() {
-	ts.faker.MonthlySegment(&model.MonthlySegment{
-		SellerId:    1,
-		AttributeId: 1,
-		CategoryId:  1,
-		Level:       10,
-		MonthOfYear: "2022-11",
-	}, true)
+	err := harness.SeedDataset(ts.db, "testdata/migrate_segment/existing_segment.yaml", model.MonthlySegment{})
+	ts.Require().NoError(err)
 	fileReader, err := os.ReadFile("test_data/migrate_segment/upsert.xlsx")
 	assert.Nil(ts.T(), err)
 	ts.excelFile, ts.getFileErr = excelize.OpenReader(bytes.NewReader(fileReader))
@@ -183,13 +170,8 @@ func (ts *migrateSegmentTest) TestCase_Upsert() {
 }
 
 func (ts *migrateSegmentTest) TestCase_RowGroupLength() {
-	ts.faker.MonthlySegment(&model.MonthlySegment{
-		SellerId:    1,
-		AttributeId: 1,
-		CategoryId:  1,
-		Level:       10,
-		MonthOfYear: "2022-11",
-	}, true)
+	err := harness.SeedDataset(ts.db, "testdata/migrate_segment/existing_segment.yaml", model.MonthlySegment{})
+	ts.Require().NoError(err)
 	fileReader, err := os.ReadFile("test_data/migrate_segment/row_group_length.xlsx")
 	assert.Nil(ts.T(), err)
 	ts.excelFile, ts.getFileErr = excelize.OpenReader(bytes.NewReader(fileReader))
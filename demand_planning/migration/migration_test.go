@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+)
+
+func TestMigrations(t *testing.T) {
+	suite.Run(t, &harness.MigrationSuite{Registry: tests.Registry})
+}
@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/internal/model"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+)
+
+// Registry lists the models of the service and the queries the tests show its repositories
+// run, the migration suite checks them against the migrations.
+var Registry = harness.ModelRegistry{
+	Models: []interface{}{
+		model.MonthlyCategory{},
+		model.MonthlySegment{},
+		model.MonthlySkuCategoryMapping{},
+		model.MonthlySkuSegmentPathMapping{},
+		model.MonthlyVariantAttribute{},
+		model.OriginalBudget{},
+		model.ScheduleJob{},
+		model.SellerConfig{},
+		model.SiteGroupDailyForecast{},
+		model.SiteGroupDemand{},
+		model.SiteSkuDailyForecast{},
+		model.SiteSkuDemand{},
+	},
+	Queries: []harness.QueryPattern{
+		// SiteSkuDemandRepository and SiteGroupDemandRepository, the demands of the seller and
+		// month read by GetDemandPlanning
+		{Model: model.SiteSkuDemand{}, Columns: []string{"seller_id", "month_of_year"}},
+		{Model: model.SiteGroupDemand{}, Columns: []string{"seller_id", "month_of_year"}},
+		// SiteSkuDailyForecastRepository and SiteGroupDailyForecastRepository, the forecasts of
+		// the demand read by GetDailyForecast
+		{Model: model.SiteSkuDailyForecast{}, Columns: []string{"site_sku_demand_id"}},
+		{Model: model.SiteGroupDailyForecast{}, Columns: []string{"site_group_demand_id"}},
+		// MonthlySegmentRepository, the segment updated in place by MigrateSegmentJobImpl.Run,
+		// see TestCase_Upsert
+		{Model: model.MonthlySegment{}, Columns: []string{"seller_id", "category_id", "attribute_id", "month_of_year"}},
+	},
+}
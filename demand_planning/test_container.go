@@ -1,13 +1,34 @@
 package tests
 
 import (
-	"os"
-)
+	"context"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+	"gorm.io/gorm"
 
-const (
-	runningEnv = "RUNNING_ENV"
+	"go.tekoapis.com/tekone/app/supplychain/demand_planning_service/config"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+	grpcCtx "go.tekoapis.com/tekone/library/grpc/ctx"
+	grpcLogger "go.tekoapis.com/tekone/library/grpc/logging"
+	"go.tekoapis.com/tekone/library/log"
 )
 
-func isLocalEnv() bool {
-	return os.Getenv(runningEnv) == "LOCAL"
+// SetUpTestMain starts a migrated MySQL container unless the tests run against a local database,
+// see harness.SetUpTestMain.
+func SetUpTestMain(m *testing.M, ctx context.Context, opts harness.DatabaseOptions) (testcontainers.Container, *gorm.DB, log.LogRPlus, bool) {
+	testContainer, _, gormDb, exit := harness.SetUpTestMain(m, ctx, opts)
+	if exit {
+		return nil, nil, nil, true
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+	serviceLog := log.NewLogRPlus(
+		cfg.Log.MustBuildLogR(),
+		grpcCtx.ExtractServerCtx,
+		grpcLogger.TagsToFields,
+	)
+	return testContainer, gormDb, serviceLog, false
 }
@@ -0,0 +1,25 @@
+package tests
+
+import (
+	"reflect"
+
+	"github.com/gogo/protobuf/types"
+
+	"go.tekoapis.com/tekone/app/supplychain/supplier_service/internal/adapter/file_service"
+	"go.tekoapis.com/tekone/app/supplychain/supplier_service/pkg/helper"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+	"go.tekoapis.com/tekone/library/test/monkey"
+)
+
+// PatchFileService makes the file service adapter upload to files instead of the file service,
+// the URL it returns opens the upload with files.OpenExcel. monkey.UnpatchAll removes the patch.
+func PatchFileService(files *harness.FakeFileService) {
+	monkey.PatchInstanceMethod(reflect.TypeOf(&file_service.FilesClientImpl{}), "UploadFile",
+		func(c *file_service.FilesClientImpl, filePath string, contentType string, isSetFileName bool) (*types.StringValue, error) {
+			url, err := files.UploadFile(filePath, contentType)
+			if err != nil {
+				return nil, err
+			}
+			return helper.StringToProtoString(url), nil
+		})
+}
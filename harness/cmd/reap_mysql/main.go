@@ -0,0 +1,22 @@
+// Command reap_mysql removes the MySQL containers shared by the integration test packages
+// when they run with MYSQL_SHARED_CONTAINER=1.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+)
+
+func main() {
+	reaped, err := harness.ReapSharedMySqlContainers(context.Background())
+	for _, name := range reaped {
+		fmt.Println("removed", name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not remove shared mysql containers:", err)
+		os.Exit(1)
+	}
+}
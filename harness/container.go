@@ -0,0 +1,275 @@
+package harness
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	migrateV4 "github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/mysql"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+type (
+	// sharedContainer is the container shared by the test packages, terminating it only
+	// drops the database copy of the package, the container is left to the reaper.
+	sharedContainer struct {
+		testcontainers.Container
+		drop func() error
+	}
+)
+
+const (
+	timeOutCreateContainer = 2
+	dbTestDatabase         = "test"
+	dbTestUserName         = "root"
+	dbTestPassword         = "root"
+	dbTestOption           = "?parseTime=true&timeout=90s"
+	maxLookUpDir           = 20
+
+	// sharedContainerEnv set to "1" makes the test packages share one container per migration set
+	sharedContainerEnv   = "MYSQL_SHARED_CONTAINER"
+	sharedContainerName  = "supplychain-tests-mysql"
+	sharedContainerLabel = "go.tekoapis.com/supplychain-tests.migrations"
+	// migrationHashLength is the number of hex characters of the migration hash kept in names
+	migrationHashLength = 12
+	startContainerTries = 3
+	// startContainerWait is the pause between two tries, so the package creating the shared
+	// container has time to start it
+	startContainerWait = 2 * time.Second
+)
+
+// SetUpTestMain runs the tests and exits when they run against a local database, otherwise
+// it starts a migrated MySQL container and returns it, the caller has to run the tests.
+// The container is shared with the other test packages when MYSQL_SHARED_CONTAINER is "1".
+// opts relax the schema of the database of the package, never the one of the shared template.
+func SetUpTestMain(m *testing.M, ctx context.Context, opts DatabaseOptions) (testcontainers.Container, *MySQLEnv, *gorm.DB, bool) {
+	// if local, run test and return
+	if IsLocalEnv() {
+		exitVal := m.Run()
+		os.Exit(exitVal)
+		return nil, nil, nil, true
+	}
+	// else init docker db
+	setup := SetupMySqlContainer
+	if os.Getenv(sharedContainerEnv) == "1" {
+		setup = SetupSharedMySqlContainer
+	}
+	testContainer, env, gormDb, err := setup(ctx)
+	if err != nil {
+		panic(err)
+	}
+	if err = relaxSchema(gormDb, opts); err != nil {
+		panic(err)
+	}
+	return testContainer, env, gormDb, false
+}
+
+// SetupMySqlContainer starts a MySQL container and applies the sql/migrations of the service,
+// found in the test directory or one of its parents.
+func SetupMySqlContainer(ctx context.Context) (testcontainers.Container, *MySQLEnv, *gorm.DB, error) {
+	migrationDir, err := findMigrationDir()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	containerDb, env, err := startMySqlContainer(ctx, "")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	db, err := migrateDatabase(env, migrationDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return containerDb, env, db, nil
+}
+
+// SetupSharedMySqlContainer reuses the container labeled with the hash of the migrations, or
+// starts it. The migrations are applied once to its template database, then the package gets
+// its own copy of it. Terminating the returned container only drops that copy, the container
+// itself is removed by ReapSharedMySqlContainers.
+func SetupSharedMySqlContainer(ctx context.Context) (testcontainers.Container, *MySQLEnv, *gorm.DB, error) {
+	migrationDir, err := findMigrationDir()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	hash, err := migrationHash(migrationDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	containerDb, env, err := startMySqlContainer(ctx, hash)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Concurrent packages wait for the migration lock, then find nothing to apply
+	template, err := migrateDatabase(env, migrationDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	db, drop, err := NewDatabaseCopy(template)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	copyEnv := *env
+	copyEnv.Database = db.Migrator().CurrentDatabase()
+	return &sharedContainer{
+		Container: containerDb,
+		drop: func() error {
+			err := drop()
+			if sqlDB, e := template.DB(); e == nil {
+				_ = sqlDB.Close()
+			}
+			return err
+		},
+	}, &copyEnv, db, nil
+}
+
+func (c *sharedContainer) Terminate(context.Context) error {
+	return c.drop()
+}
+
+// startMySqlContainer starts a new container, or reuses the shared one of the migrations
+// hash when it is not empty.
+func startMySqlContainer(ctx context.Context, hash string) (testcontainers.Container, *MySQLEnv, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, timeOutCreateContainer*time.Minute)
+	defer cancel()
+	// create container
+	req := testcontainers.ContainerRequest{
+		Image:        "mysql:8.0",
+		ExposedPorts: []string{"3306/tcp"},
+		Env: map[string]string{
+			"MYSQL_ROOT_PASSWORD": dbTestPassword,
+			"MYSQL_DATABASE":      dbTestDatabase,
+			"TZ":                  "UTC",
+		},
+		WaitingFor: wait.ForAll(
+			wait.ForLog("port: 3306  MySQL Community Server"),
+			wait.ForListeningPort("3306/tcp"),
+		),
+	}
+	reuse := hash != ""
+	if reuse {
+		req.Name = fmt.Sprintf("%s-%s", sharedContainerName, hash)
+		req.Labels = map[string]string{sharedContainerLabel: hash}
+		// The container outlives the package, it is removed by ReapSharedMySqlContainers
+		req.SkipReaper = true
+	}
+	var containerDb testcontainers.Container
+	var err error
+	for i := 0; i < startContainerTries; i++ {
+		if i > 0 {
+			time.Sleep(startContainerWait)
+		}
+		// Another package may be creating the shared container at the same time
+		containerDb, err = testcontainers.GenericContainer(ctxTimeout, testcontainers.GenericContainerRequest{
+			ContainerRequest: req,
+			Started:          true,
+			Reuse:            reuse,
+		})
+		if err == nil || !reuse {
+			break
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	containerHost, err := containerDb.Host(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//Getting mapped port from started container
+	mappedPort, err := containerDb.MappedPort(ctx, "3306/tcp")
+	if err != nil {
+		return nil, nil, err
+	}
+	port, err := strconv.Atoi(mappedPort.Port())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return containerDb, &MySQLEnv{
+		Host:     containerHost,
+		Port:     port,
+		UserName: dbTestUserName,
+		Password: dbTestPassword,
+		Database: dbTestDatabase,
+		Options:  dbTestOption,
+	}, nil
+}
+
+func migrateDatabase(env *MySQLEnv, migrationDir string) (*gorm.DB, error) {
+	// migrate
+	sourceURL := fmt.Sprintf("file://%s", migrationDir)
+	migrate, err := migrateV4.New(sourceURL, env.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = migrate.Up(); err != nil && err != migrateV4.ErrNoChange {
+		return nil, err
+	}
+
+	return gorm.Open(mysql.Open(env.DSN()), &gorm.Config{
+		Logger: gormLogger.Default.LogMode(gormLogger.Info),
+	})
+}
+
+func findMigrationDir() (string, error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for i := 0; i < maxLookUpDir; i++ {
+		serviceRootDir := currentDir + strings.Repeat("/..", i)
+		migrationDir := filepath.Join(serviceRootDir, "./sql/migrations")
+		if _, err = os.Stat(migrationDir); err == nil {
+			return migrationDir, nil
+		}
+	}
+	return "", fmt.Errorf("could not find sql/migrations from %s", currentDir)
+}
+
+// migrationHash hashes the names and contents of the files of dir, so any change to the
+// migrations gets a new shared container.
+func migrationHash(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	for _, name := range names {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		_, _ = io.WriteString(hash, name+"\n")
+		_, err = io.Copy(hash, file)
+		_ = file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:migrationHashLength], nil
+}
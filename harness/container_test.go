@@ -0,0 +1,38 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("000001_init.up.sql", "CREATE TABLE supplier (id INT);")
+	write("000001_init.down.sql", "DROP TABLE supplier;")
+
+	hash, err := migrationHash(dir)
+	assert.Nil(t, err)
+	assert.Len(t, hash, migrationHashLength)
+	same, err := migrationHash(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, same)
+
+	write("000002_contact.up.sql", "ALTER TABLE supplier ADD contact TEXT;")
+	added, err := migrationHash(dir)
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, added)
+
+	write("000002_contact.up.sql", "ALTER TABLE supplier ADD contact VARCHAR(255);")
+	changed, err := migrationHash(dir)
+	assert.Nil(t, err)
+	assert.NotEqual(t, added, changed)
+
+	_, err = migrationHash(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,333 @@
+package harness
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sebdah/goldie/v2"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type (
+	// DatasetGolden compares whole tables with a golden dataset: a JSON object which maps each
+	// table to its rows, as the JSON of the model, in primary key order. SeedDataset inserts
+	// the same format, so a golden dataset can seed another test.
+	DatasetGolden struct {
+		Normalizer GoldenNormalizer
+		// OrderBy replaces the primary key order of a table, for tables whose rows are inserted
+		// in no particular order and whose ids are normalized
+		OrderBy map[string]string
+	}
+)
+
+const seedBatchSize = 100
+
+// Assert dumps the tables of models, in the order of models, and compares them with the golden
+// dataset name of g after normalization.
+func (d DatasetGolden) Assert(t *testing.T, g *goldie.Goldie, db *gorm.DB, name string, models ...interface{}) {
+	t.Helper()
+	dataset, err := d.dump(db, models...)
+	if err != nil {
+		t.Fatalf("could not dump the tables: %v", err)
+	}
+	d.Normalizer.AssertJson(t, g, name, dataset)
+}
+
+func (d DatasetGolden) dump(db *gorm.DB, models ...interface{}) (*jsonObject, error) {
+	dataset := &jsonObject{values: make(map[string]interface{})}
+	for _, model := range models {
+		sch, err := parseModel(db, model)
+		if err != nil {
+			return nil, err
+		}
+		order := d.OrderBy[sch.Table]
+		if order == "" {
+			order = primaryOrder(sch)
+		}
+		sliceType := reflect.SliceOf(reflect.PtrTo(sch.ModelType))
+		rows := reflect.New(sliceType)
+		rows.Elem().Set(reflect.MakeSlice(sliceType, 0, 0))
+		if err = db.Table(sch.Table).Order(order).Find(rows.Interface()).Error; err != nil {
+			return nil, fmt.Errorf("dump table %s: %w", sch.Table, err)
+		}
+		if _, ok := dataset.values[sch.Table]; !ok {
+			dataset.keys = append(dataset.keys, sch.Table)
+		}
+		dataset.values[sch.Table] = rows.Elem().Interface()
+	}
+	return dataset, nil
+}
+
+// primaryOrder orders by the primary key, or by every column for tables without one.
+func primaryOrder(sch *schema.Schema) string {
+	columns := sch.PrimaryFieldDBNames
+	if len(columns) == 0 {
+		columns = sch.DBNames
+	}
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, "`"+column+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// SeedDataset inserts the rows of the dataset file, JSON or YAML, into the tables of models, in
+// the order of models. The rows are written with the field names of the models, as in a golden
+// dataset, or with the column names. The fields holding a placeholder are left to the database.
+func SeedDataset(db *gorm.DB, file string, models ...interface{}) error {
+	dataset, err := readDataset(file)
+	if err != nil {
+		return err
+	}
+	return seedDataset(db, dataset, file, models...)
+}
+
+func seedDataset(db *gorm.DB, dataset *jsonObject, source string, models ...interface{}) error {
+	schemas := make([]*schema.Schema, 0, len(models))
+	tables := make(map[string]bool)
+	for _, model := range models {
+		sch, err := parseModel(db, model)
+		if err != nil {
+			return err
+		}
+		schemas = append(schemas, sch)
+		tables[sch.Table] = true
+	}
+	for _, table := range dataset.keys {
+		if !tables[table] {
+			return fmt.Errorf("%s: table %s has no model", source, table)
+		}
+	}
+	for _, sch := range schemas {
+		rows, err := datasetRows(dataset, sch)
+		if err != nil {
+			return fmt.Errorf("%s: table %s: %w", source, sch.Table, err)
+		}
+		if rows.Len() == 0 {
+			continue
+		}
+		if err = db.Table(sch.Table).CreateInBatches(rows.Interface(), seedBatchSize).Error; err != nil {
+			return fmt.Errorf("%s: seed table %s: %w", source, sch.Table, err)
+		}
+	}
+	return nil
+}
+
+func readDataset(file string) (*jsonObject, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var node interface{}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		node, err = decodeYAML(content)
+	default:
+		node, err = decodeJSON(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	dataset, ok := node.(*jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("%s: a dataset maps the tables to their rows", file)
+	}
+	return dataset, nil
+}
+
+// decodeYAML decodes content like decodeJSON, the merge keys are applied.
+func decodeYAML(content []byte) (interface{}, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return &jsonObject{values: make(map[string]interface{})}, nil
+	}
+	return yamlValue(document.Content[0])
+}
+
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		object := &jsonObject{values: make(map[string]interface{})}
+		var merged []*yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				merged = append(merged, value)
+				continue
+			}
+			child, err := yamlValue(value)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := object.values[key.Value]; !ok {
+				object.keys = append(object.keys, key.Value)
+			}
+			object.values[key.Value] = child
+		}
+		// The keys of the mapping win over the merged ones
+		for _, value := range merged {
+			child, err := yamlValue(value)
+			if err != nil {
+				return nil, err
+			}
+			for _, base := range mergedObjects(child) {
+				for _, key := range base.keys {
+					if _, ok := object.values[key]; !ok {
+						object.keys = append(object.keys, key)
+						object.values[key] = base.values[key]
+					}
+				}
+			}
+		}
+		return object, nil
+	case yaml.SequenceNode:
+		array := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			child, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, child)
+		}
+		return array, nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	// Go through JSON so the scalars have the types of decodeJSON
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return decodeJSON(content)
+}
+
+func mergedObjects(node interface{}) []*jsonObject {
+	switch value := node.(type) {
+	case *jsonObject:
+		return []*jsonObject{value}
+	case []interface{}:
+		var objects []*jsonObject
+		for _, item := range value {
+			if object, ok := item.(*jsonObject); ok {
+				objects = append(objects, object)
+			}
+		}
+		return objects
+	}
+	return nil
+}
+
+// datasetRows decodes the rows of the table of sch into a slice of models.
+func datasetRows(dataset *jsonObject, sch *schema.Schema) (reflect.Value, error) {
+	sliceType := reflect.SliceOf(reflect.PtrTo(sch.ModelType))
+	rows := reflect.MakeSlice(sliceType, 0, 0)
+	node, ok := dataset.values[sch.Table]
+	if !ok || node == nil {
+		return rows, nil
+	}
+	items, ok := node.([]interface{})
+	if !ok {
+		return rows, fmt.Errorf("the rows are not a list")
+	}
+	for i, item := range items {
+		row, ok := item.(*jsonObject)
+		if !ok {
+			return rows, fmt.Errorf("row %d is not an object", i)
+		}
+		model, err := decodeRow(row, sch)
+		if err != nil {
+			return rows, fmt.Errorf("row %d: %w", i, err)
+		}
+		rows = reflect.Append(rows, model)
+	}
+	return rows, nil
+}
+
+// decodeRow decodes row into a new model. The fields may be named after the struct fields, the
+// columns or the JSON names, and the sql.Null types, which are objects in JSON, may be written
+// as plain values. The fields holding a placeholder are left to the database.
+func decodeRow(row *jsonObject, sch *schema.Schema) (reflect.Value, error) {
+	fields := &jsonObject{values: make(map[string]interface{})}
+	scanned := make(map[string]interface{})
+	for _, key := range row.keys {
+		value := row.values[key]
+		if text, ok := value.(string); ok && isPlaceholder(text) {
+			continue
+		}
+		name := key
+		if field := rowField(sch, key); field != nil {
+			name, _ = jsonName(field.StructField)
+			if value != nil && isScanner(field.FieldType) {
+				if _, ok := value.(*jsonObject); !ok {
+					scanned[field.Name] = value
+					continue
+				}
+			}
+		}
+		fields.keys = append(fields.keys, name)
+		fields.values[name] = value
+	}
+	content, err := json.Marshal(fields)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	model := reflect.New(sch.ModelType)
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(model.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	for name, value := range scanned {
+		scanner := model.Elem().FieldByName(name).Addr().Interface().(sql.Scanner)
+		if err = scanner.Scan(scanValue(value)); err != nil {
+			return reflect.Value{}, fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return model, nil
+}
+
+func rowField(sch *schema.Schema, key string) *schema.Field {
+	if field := sch.LookUpField(key); field != nil {
+		return field
+	}
+	for _, field := range sch.Fields {
+		if name, named := jsonName(field.StructField); named && name == key {
+			return field
+		}
+	}
+	return nil
+}
+
+func isScanner(fieldType reflect.Type) bool {
+	pointer := reflect.PtrTo(fieldType)
+	return pointer.Implements(reflect.TypeOf((*sql.Scanner)(nil)).Elem()) &&
+		!pointer.Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem())
+}
+
+// scanValue converts a JSON value to a value of the database driver.
+func scanValue(value interface{}) interface{} {
+	if number, ok := value.(json.Number); ok {
+		if integer, err := number.Int64(); err == nil {
+			return integer
+		}
+		float, _ := number.Float64()
+		return float
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+)
+
+type datasetTerm struct {
+	SupplierId int64 `gorm:"primaryKey"`
+	SiteId     int64 `gorm:"primaryKey"`
+	LeadTime   int32
+	ApprovedAt time.Time `golden:"any-time"`
+}
+
+func TestSeedDataset_Rows(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "suppliers.yaml")
+	assert.Nil(t, os.WriteFile(file, []byte(`
+supplier:
+  - Id: <any-id>
+    Code: SUP-1
+    Name: Teko
+    CreatedAt: 2024-01-02T03:04:05Z
+  - code: SUP-2
+    name: Phong Vu
+    email: sales@phongvu.vn
+    created_at: <any-time>
+`), 0644))
+	dataset, err := readDataset(file)
+	assert.Nil(t, err)
+	sch, err := schema.Parse(&driftSupplier{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.Nil(t, err)
+
+	rows, err := datasetRows(dataset, sch)
+	assert.Nil(t, err)
+	suppliers := rows.Interface().([]*driftSupplier)
+	assert.Len(t, suppliers, 2)
+	assert.Equal(t, int64(0), suppliers[0].Id)
+	assert.Equal(t, "SUP-1", suppliers[0].Code)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), suppliers[0].CreatedAt.UTC())
+	assert.Equal(t, "Phong Vu", suppliers[1].Name)
+	assert.Equal(t, "sales@phongvu.vn", *suppliers[1].Email)
+	assert.True(t, suppliers[1].CreatedAt.IsZero())
+
+	term, err := schema.Parse(&datasetTerm{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.Nil(t, err)
+	rows, err = datasetRows(dataset, term)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, rows.Len())
+}
+
+func TestSeedDataset_UnknownColumn(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "suppliers.json")
+	assert.Nil(t, os.WriteFile(file, []byte(`{"supplier": [{"Code": "SUP-1", "Rank": 1}]}`), 0644))
+	dataset, err := readDataset(file)
+	assert.Nil(t, err)
+	sch, err := schema.Parse(&driftSupplier{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.Nil(t, err)
+
+	_, err = datasetRows(dataset, sch)
+	assert.EqualError(t, err, `row 0: json: unknown field "Rank"`)
+
+	assert.Nil(t, os.WriteFile(file, []byte(`[{"Code": "SUP-1"}]`), 0644))
+	_, err = readDataset(file)
+	assert.NotNil(t, err)
+}
+
+func TestPrimaryOrder(t *testing.T) {
+	sch, err := schema.Parse(&driftSupplier{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.Nil(t, err)
+	assert.Equal(t, "`id`", primaryOrder(sch))
+
+	sch, err = schema.Parse(&datasetTerm{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.Nil(t, err)
+	assert.Equal(t, "`supplier_id`, `site_id`", primaryOrder(sch))
+}
+
+func TestDatasetGolden_Normalize(t *testing.T) {
+	dataset := &jsonObject{
+		keys: []string{"supplier", "dataset_term"},
+		values: map[string]interface{}{
+			"supplier":     []*driftSupplier{{Id: 3, Code: "SUP-1", CreatedAt: time.Now()}},
+			"dataset_term": []*datasetTerm{{SupplierId: 3, SiteId: 1, LeadTime: 7, ApprovedAt: time.Now()}},
+		},
+	}
+	normalizer := GoldenNormalizer{Fields: map[string]string{"supplier.*.Id": AnyID, "CreatedAt": AnyTime}}
+	content, err := normalizer.normalize(dataset, filepath.Join(t.TempDir(), "missing.golden"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), `"Id": "<any-id>"`)
+	assert.Contains(t, string(content), `"CreatedAt": "<any-time>"`)
+	assert.Contains(t, string(content), `"SupplierId": 3`)
+	assert.Contains(t, string(content), `"ApprovedAt": "<any-time>"`)
+}
@@ -0,0 +1,265 @@
+package harness
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type (
+	// QueryPattern is a set of columns a repository filters Model on, it needs an index
+	// starting with these columns, in any order.
+	QueryPattern struct {
+		Model   interface{}
+		Columns []string
+	}
+	// ModelRegistry lists the gorm models of a service and the queries of its repositories,
+	// MigrationSuite checks them against the migrated schema. Every migrated table needs a
+	// model, so a new migration can't go unchecked.
+	ModelRegistry struct {
+		Models []interface{}
+		// Queries only name models of Models
+		Queries []QueryPattern
+		// Unmapped lists the migrated tables no model reads, the table of the migration tool
+		// is always skipped
+		Unmapped []string
+	}
+	// dbTable is the migrated definition of a table.
+	dbTable struct {
+		Name    string
+		Columns map[string]*dbColumn
+		Indexes map[string][]string
+	}
+	dbColumn struct {
+		TableName  string
+		ColumnName string
+		DataType   string
+		IsNullable string
+		HasDefault bool
+	}
+	dbIndexColumn struct {
+		TableName  string
+		IndexName  string
+		ColumnName string
+	}
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	// dataTypes are the MySQL types each gorm data type can be read from
+	dataTypes = map[schema.DataType][]string{
+		schema.Bool:   {"tinyint", "bit"},
+		schema.Int:    {"tinyint", "smallint", "mediumint", "int", "bigint"},
+		schema.Uint:   {"tinyint", "smallint", "mediumint", "int", "bigint"},
+		schema.Float:  {"float", "double", "decimal"},
+		schema.String: {"char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "json"},
+		schema.Time:   {"date", "datetime", "timestamp", "time"},
+		schema.Bytes:  {"binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "json"},
+	}
+)
+
+// CheckModels compares the gorm models with the tables of db, usually freshly migrated,
+// and reports missing tables and columns, type and nullability mismatches, and required
+// columns the models never write.
+func CheckModels(t testing.TB, db *gorm.DB, models ...interface{}) {
+	t.Helper()
+	tables, err := loadTables(db)
+	if err != nil {
+		t.Fatalf("could not load the database schema: %v", err)
+	}
+	for _, model := range models {
+		sch, err := parseModel(db, model)
+		if err != nil {
+			t.Errorf("could not parse model %T: %v", model, err)
+			continue
+		}
+		for _, drift := range modelDrift(sch, tables[sch.Table]) {
+			t.Errorf("%T: %s", model, drift)
+		}
+	}
+}
+
+// CheckQueryIndexes reports the query patterns no index of db can serve.
+func CheckQueryIndexes(t testing.TB, db *gorm.DB, patterns ...QueryPattern) {
+	t.Helper()
+	tables, err := loadTables(db)
+	if err != nil {
+		t.Fatalf("could not load the database schema: %v", err)
+	}
+	for _, pattern := range patterns {
+		sch, err := parseModel(db, pattern.Model)
+		if err != nil {
+			t.Errorf("could not parse model %T: %v", pattern.Model, err)
+			continue
+		}
+		if !hasIndex(tables[sch.Table], pattern.Columns) {
+			t.Errorf("%T: no index of table %s starts with columns %s", pattern.Model, sch.Table, strings.Join(pattern.Columns, ", "))
+		}
+	}
+}
+
+// Check reports the drifts of the models, the migrated tables without a model, and the
+// queries no index of db can serve.
+func (r ModelRegistry) Check(t testing.TB, db *gorm.DB) {
+	t.Helper()
+	tables, err := loadTables(db)
+	if err != nil {
+		t.Fatalf("could not load the database schema: %v", err)
+	}
+	CheckModels(t, db, r.Models...)
+	registered := make(map[string]bool)
+	for _, model := range r.Models {
+		if sch, err := parseModel(db, model); err == nil {
+			registered[sch.Table] = true
+		}
+	}
+	for _, table := range unregisteredTables(tables, registered, append(r.Unmapped, migrationTable)) {
+		t.Errorf("table %s has no registered model", table)
+	}
+	for _, pattern := range r.Queries {
+		if sch, err := parseModel(db, pattern.Model); err == nil && !registered[sch.Table] {
+			t.Errorf("%T: the query model is not registered", pattern.Model)
+		}
+	}
+	CheckQueryIndexes(t, db, r.Queries...)
+}
+
+// unregisteredTables lists, in name order, the tables neither registered nor unmapped.
+func unregisteredTables(tables map[string]*dbTable, registered map[string]bool, unmapped []string) []string {
+	var names []string
+	for name := range tables {
+		if !registered[name] && !contains(unmapped, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseModel(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	return schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+}
+
+func loadTables(db *gorm.DB) (map[string]*dbTable, error) {
+	database := db.Migrator().CurrentDatabase()
+	var columns []*dbColumn
+	err := db.Raw(`SELECT TABLE_NAME AS table_name, COLUMN_NAME AS column_name, DATA_TYPE AS data_type,
+		IS_NULLABLE AS is_nullable, (COLUMN_DEFAULT IS NOT NULL OR EXTRA LIKE '%auto_increment%' OR EXTRA LIKE '%GENERATED%') AS has_default
+		FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ?`, database).Scan(&columns).Error
+	if err != nil {
+		return nil, err
+	}
+	var indexColumns []*dbIndexColumn
+	err = db.Raw(`SELECT TABLE_NAME AS table_name, INDEX_NAME AS index_name, COLUMN_NAME AS column_name
+		FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`, database).Scan(&indexColumns).Error
+	if err != nil {
+		return nil, err
+	}
+	tables := make(map[string]*dbTable)
+	table := func(name string) *dbTable {
+		if tables[name] == nil {
+			tables[name] = &dbTable{Name: name, Columns: make(map[string]*dbColumn), Indexes: make(map[string][]string)}
+		}
+		return tables[name]
+	}
+	for _, column := range columns {
+		table(column.TableName).Columns[column.ColumnName] = column
+	}
+	for _, column := range indexColumns {
+		index := table(column.TableName).Indexes
+		index[column.IndexName] = append(index[column.IndexName], column.ColumnName)
+	}
+	return tables, nil
+}
+
+// modelDrift lists how the model differs from its migrated table, which is nil when missing.
+func modelDrift(sch *schema.Schema, table *dbTable) []string {
+	if table == nil {
+		return []string{fmt.Sprintf("table %s does not exist", sch.Table)}
+	}
+	var drifts []string
+	written := make(map[string]bool)
+	for _, field := range sch.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		written[field.DBName] = true
+		column, ok := table.Columns[field.DBName]
+		if !ok {
+			drifts = append(drifts, fmt.Sprintf("column %s.%s does not exist", sch.Table, field.DBName))
+			continue
+		}
+		if types, known := dataTypes[field.DataType]; known && !contains(types, column.DataType) {
+			drifts = append(drifts, fmt.Sprintf("column %s.%s is %s, which does not fit %s field %s",
+				sch.Table, field.DBName, column.DataType, field.FieldType, field.Name))
+		} else if !known && field.DataType != "" && !strings.EqualFold(string(field.DataType), column.DataType) {
+			drifts = append(drifts, fmt.Sprintf("column %s.%s is %s, the model expects %s",
+				sch.Table, field.DBName, column.DataType, field.DataType))
+		}
+		nullable := column.IsNullable == "YES"
+		if nullable && !canScanNull(field.FieldType) {
+			drifts = append(drifts, fmt.Sprintf("column %s.%s is nullable but %s field %s cannot hold NULL",
+				sch.Table, field.DBName, field.FieldType, field.Name))
+		}
+		if nullable && field.NotNull {
+			drifts = append(drifts, fmt.Sprintf("column %s.%s is nullable but field %s is tagged not null",
+				sch.Table, field.DBName, field.Name))
+		}
+	}
+	var names []string
+	for name := range table.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		column := table.Columns[name]
+		if !written[name] && column.IsNullable == "NO" && !column.HasDefault {
+			drifts = append(drifts, fmt.Sprintf("column %s.%s is required but has no field", sch.Table, name))
+		}
+	}
+	return drifts
+}
+
+// hasIndex tells whether an index of table starts with columns, in any order.
+func hasIndex(table *dbTable, columns []string) bool {
+	if table == nil {
+		return false
+	}
+	for _, index := range table.Indexes {
+		if len(index) < len(columns) {
+			continue
+		}
+		covered := true
+		for _, column := range columns {
+			covered = covered && contains(index[:len(columns)], column)
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+func canScanNull(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return reflect.PtrTo(fieldType).Implements(scannerType)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
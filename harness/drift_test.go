@@ -0,0 +1,87 @@
+package harness
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+)
+
+type driftSupplier struct {
+	Id        int64
+	Code      string
+	Name      string `gorm:"not null"`
+	Email     *string
+	Phone     sql.NullString
+	Rating    float64
+	CreatedAt time.Time
+	Note      string `gorm:"-"`
+}
+
+func (driftSupplier) TableName() string {
+	return "supplier"
+}
+
+func TestModelDrift(t *testing.T) {
+	sch, err := schema.Parse(&driftSupplier{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"table supplier does not exist"}, modelDrift(sch, nil))
+
+	table := &dbTable{Name: "supplier", Columns: map[string]*dbColumn{
+		"id":         {ColumnName: "id", DataType: "bigint", IsNullable: "NO", HasDefault: true},
+		"code":       {ColumnName: "code", DataType: "varchar", IsNullable: "NO"},
+		"name":       {ColumnName: "name", DataType: "varchar", IsNullable: "NO"},
+		"email":      {ColumnName: "email", DataType: "varchar", IsNullable: "YES"},
+		"phone":      {ColumnName: "phone", DataType: "varchar", IsNullable: "YES"},
+		"rating":     {ColumnName: "rating", DataType: "decimal", IsNullable: "NO"},
+		"created_at": {ColumnName: "created_at", DataType: "datetime", IsNullable: "NO", HasDefault: true},
+	}}
+	assert.Empty(t, modelDrift(sch, table))
+
+	table.Columns["code"] = &dbColumn{ColumnName: "code", DataType: "int", IsNullable: "YES"}
+	table.Columns["name"].IsNullable = "YES"
+	delete(table.Columns, "rating")
+	table.Columns["seller_id"] = &dbColumn{ColumnName: "seller_id", DataType: "int", IsNullable: "NO"}
+	table.Columns["deleted_at"] = &dbColumn{ColumnName: "deleted_at", DataType: "datetime", IsNullable: "YES"}
+	assert.Equal(t, []string{
+		"column supplier.code is int, which does not fit string field Code",
+		"column supplier.code is nullable but string field Code cannot hold NULL",
+		"column supplier.name is nullable but string field Name cannot hold NULL",
+		"column supplier.name is nullable but field Name is tagged not null",
+		"column supplier.rating does not exist",
+		"column supplier.seller_id is required but has no field",
+	}, modelDrift(sch, table))
+}
+
+func TestHasIndex(t *testing.T) {
+	table := &dbTable{Indexes: map[string][]string{
+		"PRIMARY":             {"id"},
+		"idx_seller_code":     {"seller_id", "code", "status"},
+		"idx_created_at_name": {"created_at"},
+	}}
+
+	assert.True(t, hasIndex(table, []string{"id"}))
+	assert.True(t, hasIndex(table, []string{"code", "seller_id"}))
+	assert.True(t, hasIndex(table, []string{"seller_id"}))
+	assert.False(t, hasIndex(table, []string{"code"}))
+	assert.False(t, hasIndex(table, []string{"seller_id", "status"}))
+	assert.False(t, hasIndex(nil, []string{"id"}))
+}
+
+func TestUnregisteredTables(t *testing.T) {
+	tables := map[string]*dbTable{
+		"supplier":          {Name: "supplier"},
+		"supplier_contact":  {Name: "supplier_contact"},
+		"supplier_history":  {Name: "supplier_history"},
+		"schema_migrations": {Name: "schema_migrations"},
+	}
+
+	assert.Equal(t, []string{"supplier_contact", "supplier_history"},
+		unregisteredTables(tables, map[string]bool{"supplier": true}, []string{migrationTable}))
+	assert.Empty(t, unregisteredTables(tables, map[string]bool{"supplier": true, "supplier_contact": true},
+		[]string{"supplier_history", migrationTable}))
+}
@@ -0,0 +1,75 @@
+package harness
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// MySQLEnv is the MySQL database the integration tests run against.
+type MySQLEnv struct {
+	Host     string
+	Port     int
+	UserName string
+	Password string
+	Database string
+	Options  string
+}
+
+const (
+	runningEnv = "RUNNING_ENV"
+	local      = "LOCAL"
+
+	dbHostEnv     = "MYSQL_HOST"
+	dbPortEnv     = "MYSQL_PORT"
+	dbUserNameEnv = "MYSQL_USERNAME"
+	dbPwEnv       = "MYSQL_PASSWORD"
+	dbDatabaseEnv = "MYSQL_DATABASE"
+	dbOptionEnv   = "MYSQL_OPTIONS"
+
+	dbHostDefaultLocal   = "127.0.0.1"
+	dbPortDefaultLocal   = "3306"
+	dbUserDefaultLocal   = "root"
+	dbPwDefaultLocal     = "secret"
+	dbOptionDefaultLocal = "?parseTime=true&timeout=90s"
+)
+
+// IsLocalEnv tells whether the tests run against a local database instead of a container.
+func IsLocalEnv() bool {
+	return os.Getenv(runningEnv) == local
+}
+
+// LoadLocalMySQLEnv reads the local database from the MYSQL_* env, database is the
+// default name of the database of the service.
+func LoadLocalMySQLEnv(database string) (*MySQLEnv, error) {
+	dbPort := getEnv(dbPortEnv, dbPortDefaultLocal)
+	port, err := strconv.Atoi(dbPort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", dbPortEnv, dbPort, err)
+	}
+	return &MySQLEnv{
+		Host:     getEnv(dbHostEnv, dbHostDefaultLocal),
+		Port:     port,
+		UserName: getEnv(dbUserNameEnv, dbUserDefaultLocal),
+		Password: getEnv(dbPwEnv, dbPwDefaultLocal),
+		Database: getEnv(dbDatabaseEnv, database),
+		Options:  getEnv(dbOptionEnv, dbOptionDefaultLocal),
+	}, nil
+}
+
+// DSN is the go-sql-driver data source name of the database.
+func (e *MySQLEnv) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s%s", e.UserName, e.Password, e.Host, e.Port, e.Database, e.Options)
+}
+
+// URL is the database url used by the migrations.
+func (e *MySQLEnv) URL() string {
+	return "mysql://" + e.DSN()
+}
+
+func getEnv(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,435 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// fakeBackend holds the state shared by the fake test management servers: it counts the
+	// requests by route and answers with the statuses queued by failNext.
+	fakeBackend struct {
+		*httptest.Server
+		mu         sync.Mutex
+		projectKey string
+		seq        int
+		failures   map[string][]int
+		requests   map[string]int
+		authHeader string
+	}
+	// fakeJira is an in-memory Jira with the Zephyr/ATM endpoints used by Jira.
+	fakeJira struct {
+		*fakeBackend
+		issues     map[string]*fakeIssue
+		testCases  map[string]*TestCase
+		testCycles map[string]*TestCycle
+		folders    []*Folder
+		deleted    []string
+		updated    []string
+		results    []*fakeResult
+	}
+	fakeResult struct {
+		CycleKey    string
+		TestCaseKey string
+		Attachments map[string]string
+	}
+	fakeIssue struct {
+		Status string
+		Epic   string
+		Parent string
+	}
+)
+
+var issueKeysQuery = regexp.MustCompile(`issueKeys IN \(([^)]*)\)`)
+
+func newFakeBackend(projectKey string) *fakeBackend {
+	return &fakeBackend{
+		projectKey: projectKey,
+		failures:   make(map[string][]int),
+		requests:   make(map[string]int),
+	}
+}
+
+func newFakeJira(projectKey string) *fakeJira {
+	f := &fakeJira{
+		fakeBackend: newFakeBackend(projectKey),
+		issues:      make(map[string]*fakeIssue),
+		testCases:   make(map[string]*TestCase),
+		testCycles:  make(map[string]*TestCycle),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/issue/", f.handleIssue)
+	mux.HandleFunc("/rest/atm/1.0/testcase/search", f.handleSearchTestCase)
+	mux.HandleFunc("/rest/atm/1.0/testcase", f.handleCreateTestCase)
+	mux.HandleFunc("/rest/atm/1.0/testcase/", f.handleTestCase)
+	mux.HandleFunc("/rest/atm/1.0/testrun", f.handleCreateTestCycle)
+	mux.HandleFunc("/rest/atm/1.0/testrun/", f.handleTestCycle)
+	mux.HandleFunc("/rest/atm/1.0/testresult/", f.handleAttachment)
+	mux.HandleFunc("/rest/atm/1.0/folder", f.handleCreateFolder)
+	f.Server = httptest.NewServer(f.withFailures(mux, fakeRoute))
+	return f
+}
+
+// fakeRoute names the route of r as in failNext, such as "POST /rest/atm/1.0/testcase".
+func fakeRoute(r *http.Request) string {
+	return fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+}
+
+// withFailures counts every request and answers with the statuses queued by failNext.
+func (f *fakeBackend) withFailures(next http.Handler, route func(r *http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := route(r)
+		f.mu.Lock()
+		f.requests[route]++
+		f.authHeader = r.Header.Get("Authorization")
+		statuses := f.failures[route]
+		if len(statuses) > 0 {
+			f.failures[route] = statuses[1:]
+			f.mu.Unlock()
+			writeJson(w, statuses[0], map[string]interface{}{"errorMessages": []string{http.StatusText(statuses[0])}})
+			return
+		}
+		f.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// failNext makes the next requests of route, such as "POST /rest/atm/1.0/testcase", fail with statuses.
+func (f *fakeBackend) failNext(route string, statuses ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[route] = append(f.failures[route], statuses...)
+}
+
+func (f *fakeBackend) authorization() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.authHeader
+}
+
+func (f *fakeBackend) requestCount(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[route]
+}
+
+func (f *fakeJira) client() *Jira {
+	return &Jira{
+		HttpClientConfig: HttpClientConfig{
+			RetryWaitTime: time.Millisecond,
+			RateLimit:     1000,
+		},
+		ProjectKey: f.projectKey,
+		UserName:   "user",
+		Password:   "password",
+		Url:        f.URL,
+	}
+}
+
+func (f *fakeJira) addIssue(key string, issue *fakeIssue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.issues[key] = issue
+}
+
+// addTestCase stores a test case as if it was created by an earlier run.
+func (f *fakeJira) addTestCase(testCase *TestCase) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.storeTestCase(testCase)
+}
+
+func (f *fakeJira) testCase(key string) *TestCase {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.testCases[key]
+}
+
+func (f *fakeJira) testCaseKeys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.testCases))
+	for key := range f.testCases {
+		keys = append(keys, key)
+	}
+	return sortKeys(keys)
+}
+
+func (f *fakeJira) testCycle(key string) *TestCycle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.testCycles[key]
+}
+
+func (f *fakeJira) testCycleKeys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.testCycles))
+	for key := range f.testCycles {
+		keys = append(keys, key)
+	}
+	return sortKeys(keys)
+}
+
+func (f *fakeJira) createdFolders() []*Folder {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Folder(nil), f.folders...)
+}
+
+func (f *fakeJira) updatedKeys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.updated...)
+}
+
+func (f *fakeJira) deletedKeys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.deleted...)
+}
+
+func (f *fakeBackend) nextKey(kind string) string {
+	f.seq++
+	return fmt.Sprintf("%s-%s%d", f.projectKey, kind, f.seq)
+}
+
+func (f *fakeJira) storeTestCase(testCase *TestCase) string {
+	key := f.nextKey("T")
+	f.testCases[key] = testCase
+	return key
+}
+
+func (f *fakeJira) handleIssue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+	issue, ok := f.issues[key]
+	if !ok {
+		writeJson(w, http.StatusNotFound, map[string]interface{}{"errorMessages": []string{"Issue Does Not Exist"}})
+		return
+	}
+	fields := GetIssueFields{
+		Status:   GetIssueFieldsStatus{Name: issue.Status},
+		EpicName: issue.Epic,
+	}
+	if issue.Parent != "" {
+		fields.Parent = &GetIssueFieldsParent{Key: issue.Parent}
+	}
+	writeJson(w, http.StatusOK, &GetIssueResult{Fields: fields})
+}
+
+func (f *fakeJira) handleSearchTestCase(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	query := r.URL.Query().Get("query")
+	match := issueKeysQuery.FindStringSubmatch(query)
+	if match == nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{"invalid query"}})
+		return
+	}
+	issueKeys := map[string]bool{}
+	for _, key := range strings.Split(match[1], ",") {
+		issueKeys[strings.TrimSpace(key)] = true
+	}
+	keys := make([]string, 0, len(f.testCases))
+	for key := range f.testCases {
+		keys = append(keys, key)
+	}
+	results := make([]*TestResult, 0)
+	for _, key := range sortKeys(keys) {
+		testCase := f.testCases[key]
+		for _, link := range testCase.IssueLinks {
+			if issueKeys[link] {
+				results = append(results, &TestResult{Key: key, Name: testCase.Name, Status: testCase.Status})
+				break
+			}
+		}
+	}
+	writeJson(w, http.StatusOK, results)
+}
+
+func (f *fakeJira) handleCreateTestCase(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	testCase := &TestCase{}
+	if err := json.NewDecoder(r.Body).Decode(testCase); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	writeJson(w, http.StatusCreated, &CreateResult{Key: f.storeTestCase(testCase)})
+}
+
+func (f *fakeJira) handleTestCase(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/rest/atm/1.0/testcase/")
+	testCase := &TestCase{}
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(testCase); err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+			return
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.testCases[key]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		f.testCases[key] = testCase
+		f.updated = append(f.updated, key)
+	case http.MethodDelete:
+		delete(f.testCases, key)
+		f.deleted = append(f.deleted, key)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeJira) handleCreateTestCycle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	cycle := &TestCycle{}
+	if err := json.NewDecoder(r.Body).Decode(cycle); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := f.nextKey("C")
+	f.testCycles[key] = cycle
+	for _, item := range cycle.Items {
+		f.results = append(f.results, &fakeResult{CycleKey: key, TestCaseKey: item.TestCaseKey})
+	}
+	writeJson(w, http.StatusCreated, &CreateResult{Key: key})
+}
+
+func (f *fakeJira) handleTestCycle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/rest/atm/1.0/testrun/")
+	listResults := strings.HasSuffix(key, "/testresults")
+	key = strings.TrimSuffix(key, "/testresults")
+	if _, ok := f.testCycles[key]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch {
+	case listResults && r.Method == http.MethodGet:
+		results := make([]*TestRunResult, 0)
+		for i, result := range f.results {
+			if result.CycleKey == key {
+				results = append(results, &TestRunResult{Id: i + 1, TestCaseKey: result.TestCaseKey})
+			}
+		}
+		writeJson(w, http.StatusOK, results)
+	case !listResults && r.Method == http.MethodDelete:
+		delete(f.testCycles, key)
+		f.deleted = append(f.deleted, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeJira) handleAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/attachments") {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/atm/1.0/testresult/"), "/attachments")
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	idx, err := strconv.Atoi(id)
+	if err != nil || idx < 1 || idx > len(f.results) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	result := f.results[idx-1]
+	if result.Attachments == nil {
+		result.Attachments = make(map[string]string)
+	}
+	result.Attachments[header.Filename] = string(data)
+	writeJson(w, http.StatusCreated, &CreateResult{})
+}
+
+// attachments returns the files attached to the result of testCaseKey in cycleKey, by name.
+func (f *fakeJira) attachments(cycleKey string, testCaseKey string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, result := range f.results {
+		if result.CycleKey == cycleKey && result.TestCaseKey == testCaseKey {
+			return result.Attachments
+		}
+	}
+	return nil
+}
+
+func (f *fakeJira) handleCreateFolder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	folder := &Folder{}
+	if err := json.NewDecoder(r.Body).Decode(folder); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existed := range f.folders {
+		if *existed == *folder {
+			writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{"folder already exists"}})
+			return
+		}
+	}
+	f.folders = append(f.folders, folder)
+	writeJson(w, http.StatusCreated, &CreateResult{})
+}
+
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// sortKeys orders keys such as "OMNI-T2" and "OMNI-T10" by their sequence number.
+func sortKeys(keys []string) []string {
+	sort.Slice(keys, func(i, j int) bool {
+		return fileStoreSeq(keys[i]) < fileStoreSeq(keys[j])
+	})
+	return keys
+}
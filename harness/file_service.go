@@ -0,0 +1,125 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v3"
+)
+
+type (
+	// Upload is a file received by FakeFileService.
+	Upload struct {
+		Name        string
+		ContentType string
+		// Path is the local file the service uploaded, empty when uploaded from memory
+		Path    string
+		URL     string
+		Content []byte
+	}
+	// FakeFileService keeps in memory the files the services upload. The service tests patch
+	// the upload method of their file service adapter to call it, then open the uploads back
+	// from the URL the service returned.
+	FakeFileService struct {
+		mu      sync.Mutex
+		uploads []*Upload
+	}
+)
+
+const fakeFileServiceURL = "https://files.fake/uploads/%d/%s"
+
+// contentTypes are the content types of the files exported by the services
+var contentTypes = map[string]string{
+	".csv":  "text/csv",
+	".json": "application/json",
+	".pdf":  "application/pdf",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func NewFakeFileService() *FakeFileService {
+	return &FakeFileService{}
+}
+
+// Upload stores content and returns its URL. The URL only depends on the name and the
+// number of previous uploads, so it is stable from one run to another.
+func (f *FakeFileService) Upload(name string, contentType string, content []byte) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.add(&Upload{Name: name, ContentType: contentType, Content: content})
+}
+
+// UploadFile stores the local file the service uploads, which is usually removed once
+// uploaded. The content type is guessed from the extension when empty.
+func (f *FakeFileService) UploadFile(filePath string, contentType string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(filePath)
+	if contentType == "" {
+		contentType = contentTypes[strings.ToLower(filepath.Ext(name))]
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.add(&Upload{Name: name, ContentType: contentType, Path: filePath, Content: content}), nil
+}
+
+func (f *FakeFileService) add(upload *Upload) string {
+	upload.URL = fmt.Sprintf(fakeFileServiceURL, len(f.uploads)+1, url.PathEscape(upload.Name))
+	f.uploads = append(f.uploads, upload)
+	return upload.URL
+}
+
+// Get finds an upload by URL, local path or name, the latest one when several match.
+func (f *FakeFileService) Get(key string) (*Upload, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.uploads) - 1; i >= 0; i-- {
+		upload := f.uploads[i]
+		if upload.URL == key || upload.Path == key || upload.Name == key {
+			return upload, true
+		}
+	}
+	return nil, false
+}
+
+// Uploads returns the uploads in the order they were received.
+func (f *FakeFileService) Uploads() []*Upload {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Upload(nil), f.uploads...)
+}
+
+// Reset forgets the uploads, the next URLs start over.
+func (f *FakeFileService) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads = nil
+}
+
+// OpenExcel opens the workbook uploaded under key, see Get.
+func (f *FakeFileService) OpenExcel(key string) (*excelize.File, error) {
+	upload, ok := f.Get(key)
+	if !ok {
+		return nil, fmt.Errorf("no file uploaded as %s", key)
+	}
+	return excelize.OpenReader(bytes.NewReader(upload.Content))
+}
+
+// ReadCSV reads the records of the csv file uploaded under key, see Get.
+func (f *FakeFileService) ReadCSV(key string) ([][]string, error) {
+	upload, ok := f.Get(key)
+	if !ok {
+		return nil, fmt.Errorf("no file uploaded as %s", key)
+	}
+	reader := csv.NewReader(bytes.NewReader(upload.Content))
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
@@ -0,0 +1,62 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeFileService_Excel(t *testing.T) {
+	workbook := excelize.NewFile()
+	assert.Nil(t, workbook.SetCellValue("Sheet1", "A1", "sku"))
+	assert.Nil(t, workbook.SetCellValue("Sheet1", "B1", 12))
+	filePath := filepath.Join(t.TempDir(), "export forecast.xlsx")
+	assert.Nil(t, workbook.SaveAs(filePath))
+
+	files := NewFakeFileService()
+	url, err := files.UploadFile(filePath, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://files.fake/uploads/1/export%20forecast.xlsx", url)
+	// The export removes its file once uploaded
+	assert.Nil(t, os.Remove(filePath))
+
+	for _, key := range []string{url, filePath, "export forecast.xlsx"} {
+		actual, err := files.OpenExcel(key)
+		assert.Nil(t, err, key)
+		rows, err := actual.GetRows("Sheet1")
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"sku", "12"}}, rows)
+	}
+	upload, ok := files.Get(url)
+	assert.True(t, ok)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", upload.ContentType)
+
+	_, err = files.OpenExcel("missing.xlsx")
+	assert.NotNil(t, err)
+	_, err = files.UploadFile(filePath, "")
+	assert.NotNil(t, err)
+}
+
+func TestFakeFileService_CSV(t *testing.T) {
+	files := NewFakeFileService()
+	first := files.Upload("suppliers.csv", "text/csv", []byte("code,name\ns1,Supplier 1\n"))
+	second := files.Upload("suppliers.csv", "text/csv", []byte("code\ns2\n"))
+	assert.Equal(t, "https://files.fake/uploads/1/suppliers.csv", first)
+	assert.Equal(t, "https://files.fake/uploads/2/suppliers.csv", second)
+
+	records, err := files.ReadCSV(first)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"code", "name"}, {"s1", "Supplier 1"}}, records)
+	// By name, the latest upload wins
+	records, err = files.ReadCSV("suppliers.csv")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"code"}, {"s2"}}, records)
+	assert.Len(t, files.Uploads(), 2)
+
+	files.Reset()
+	assert.Empty(t, files.Uploads())
+	assert.Equal(t, first, files.Upload("suppliers.csv", "text/csv", nil))
+}
@@ -0,0 +1,253 @@
+package harness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	// FileStore keeps test cases and cycles as json files under Dir so PushTests can run
+	// offline and its output can be reviewed as a plain diff:
+	//
+	//	issues/<issueKey>.json                   optional, hand written Issue used by GetIssue
+	//	testcases/<testKey>.json                 one file per created TestCase
+	//	cycles/<cycleKey>.json                   the last TestCycle of an issue and folder
+	//	attachments/<cycleKey>/<testKey>/<name>  files attached to a cycle item
+	//	folders.json                             sorted list of created folders
+	FileStore struct {
+		ProjectKey string
+		Dir        string
+		mu         sync.Mutex
+	}
+	FileStoreTestCase struct {
+		Key string `json:"key"`
+		*TestCase
+	}
+	FileStoreTestCycle struct {
+		Key string `json:"key"`
+		*TestCycle
+	}
+)
+
+const (
+	fileStoreIssueDir      = "issues"
+	fileStoreTestCaseDir   = "testcases"
+	fileStoreCycleDir      = "cycles"
+	fileStoreAttachmentDir = "attachments"
+	fileStoreFolderFile    = "folders.json"
+	fileStoreTestPrefix    = "T"
+	fileStoreCyclePrefix   = "C"
+)
+
+func (f *FileStore) GetIssue(issueKey string) (*Issue, error) {
+	issue := &Issue{}
+	if err := f.read(filepath.Join(fileStoreIssueDir, issueKey+".json"), issue); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	issue.Key = issueKey
+	return issue, nil
+}
+
+func (f *FileStore) ListTests(issueKey string) ([]*TestResult, error) {
+	keys, err := f.keys(fileStoreTestCaseDir)
+	if err != nil {
+		return nil, err
+	}
+	var tests []*TestResult
+	for _, key := range keys {
+		testCase := &FileStoreTestCase{TestCase: &TestCase{}}
+		if err = f.read(filepath.Join(fileStoreTestCaseDir, key+".json"), testCase); err != nil {
+			return nil, err
+		}
+		for _, link := range testCase.IssueLinks {
+			if link == issueKey {
+				tests = append(tests, &TestResult{
+					Key:    key,
+					Name:   testCase.Name,
+					Status: testCase.Status,
+				})
+				break
+			}
+		}
+	}
+	return tests, nil
+}
+
+func (f *FileStore) CreateTest(testCase *TestCase) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	testCase.ProjectKey = f.ProjectKey
+	key, err := f.nextKey(fileStoreTestCaseDir, fileStoreTestPrefix)
+	if err != nil {
+		return "", err
+	}
+	err = f.write(filepath.Join(fileStoreTestCaseDir, key+".json"), &FileStoreTestCase{Key: key, TestCase: testCase})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (f *FileStore) UpdateTest(testKey string, testCase *TestCase) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	testCase.ProjectKey = f.ProjectKey
+	name := filepath.Join(fileStoreTestCaseDir, testKey+".json")
+	if _, err := os.Stat(filepath.Join(f.Dir, name)); err != nil {
+		return err
+	}
+	return f.write(name, &FileStoreTestCase{Key: testKey, TestCase: testCase})
+}
+
+func (f *FileStore) DeleteTest(testKey string) error {
+	return os.Remove(filepath.Join(f.Dir, fileStoreTestCaseDir, testKey+".json"))
+}
+
+// CreateTestCycle stores the cycle without its planned dates to keep the files stable between runs.
+// The cycle of an earlier run for the same issue and folder is overwritten, attachments included.
+func (f *FileStore) CreateTestCycle(cycle *TestCycle) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cycle.ProjectKey = f.ProjectKey
+	key, err := f.cycleKey(cycle)
+	if err != nil {
+		return "", err
+	}
+	if err = os.RemoveAll(filepath.Join(f.Dir, fileStoreAttachmentDir, key)); err != nil {
+		return "", err
+	}
+	stored := *cycle
+	stored.PlannedStartDate = ""
+	stored.PlannedEndDate = ""
+	if err = f.write(filepath.Join(fileStoreCycleDir, key+".json"), &FileStoreTestCycle{Key: key, TestCycle: &stored}); err != nil {
+		return "", err
+	}
+	for _, item := range cycle.Items {
+		for _, attachment := range item.Attachments {
+			dir := filepath.Join(f.Dir, fileStoreAttachmentDir, key, item.TestCaseKey)
+			if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+				return key, err
+			}
+			if err = os.WriteFile(filepath.Join(dir, attachment.Name), attachment.Data, 0o644); err != nil {
+				return key, err
+			}
+		}
+	}
+	return key, nil
+}
+
+// cycleKey returns the key of the stored cycle of the issue and folder of cycle, or a new key.
+func (f *FileStore) cycleKey(cycle *TestCycle) (string, error) {
+	keys, err := f.keys(fileStoreCycleDir)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range keys {
+		stored := &FileStoreTestCycle{TestCycle: &TestCycle{}}
+		if err = f.read(filepath.Join(fileStoreCycleDir, key+".json"), stored); err != nil {
+			return "", err
+		}
+		if stored.IssueKey == cycle.IssueKey && stored.Folder == cycle.Folder {
+			return key, nil
+		}
+	}
+	return f.nextKey(fileStoreCycleDir, fileStoreCyclePrefix)
+}
+
+func (f *FileStore) DeleteTestCycle(cycleKey string) error {
+	if err := os.RemoveAll(filepath.Join(f.Dir, fileStoreAttachmentDir, cycleKey)); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(f.Dir, fileStoreCycleDir, cycleKey+".json"))
+}
+
+func (f *FileStore) CreateFolder(folder string) error {
+	var folders []string
+	if err := f.read(fileStoreFolderFile, &folders); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, existed := range folders {
+		if existed == folder {
+			return nil
+		}
+	}
+	folders = append(folders, folder)
+	sort.Strings(folders)
+	return f.write(fileStoreFolderFile, folders)
+}
+
+func (f *FileStore) read(name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(f.Dir, name))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (f *FileStore) write(name string, v interface{}) error {
+	path := filepath.Join(f.Dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// keys lists the keys stored in dir, sorted by their sequence number.
+func (f *FileStore) keys(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(f.Dir, dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		keys = append(keys, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fileStoreSeq(keys[i]) < fileStoreSeq(keys[j])
+	})
+	return keys, nil
+}
+
+func (f *FileStore) nextKey(dir string, prefix string) (string, error) {
+	keys, err := f.keys(dir)
+	if err != nil {
+		return "", err
+	}
+	next := 1
+	for _, key := range keys {
+		if seq := fileStoreSeq(key); seq >= next {
+			next = seq + 1
+		}
+	}
+	return fmt.Sprintf("%s-%s%d", f.ProjectKey, prefix, next), nil
+}
+
+// fileStoreSeq returns the sequence number of keys such as "OMNI-T12", or 0 when there is none.
+func fileStoreSeq(key string) int {
+	idx := strings.LastIndex(key, "-")
+	if idx < 0 || idx+2 > len(key) {
+		return 0
+	}
+	seq, err := strconv.Atoi(key[idx+2:])
+	if err != nil {
+		return 0
+	}
+	return seq
+}
@@ -0,0 +1,134 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readStoreFile(t *testing.T, dir string, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func TestFileStore_PushTests(t *testing.T) {
+	t.Setenv(removeOldTestEnv, "")
+	dir := t.TempDir()
+	store := &FileStore{ProjectKey: fakeProjectKey, Dir: dir}
+	assert.Nil(t, store.write("issues/OMNI-1.json", &Issue{Status: "Open", Epic: "Demand planning"}))
+	// A test of an earlier run, it is updated by name
+	key, err := store.CreateTest(&TestCase{Name: "happy case", IssueLinks: []string{"OMNI-1"}, Status: "Approved"})
+	assert.Nil(t, err)
+	assert.Equal(t, "OMNI-T1", key)
+
+	testCases := []*TestCase{
+		{Name: "happy case", Status: "Pass", ExecutionTime: 1500},
+		{Name: "error case", Status: "Fail", ExecutionTime: 20, Comment: "expected: 1, actual: 2", Logs: "request: {}\n"},
+	}
+	err = NewTestData(store).PushTests("OMNI-1", fakeApiName, fakeFolder, testCases)
+	assert.Nil(t, err)
+
+	assert.Equal(t, `[
+  "/HN7/API/SC/demand_planning"
+]
+`, readStoreFile(t, dir, "folders.json"))
+	assert.Equal(t, `{
+  "key": "OMNI-T1",
+  "name": "happy case",
+  "projectKey": "OMNI",
+  "issueLinks": [
+    "OMNI-1"
+  ],
+  "objective": "",
+  "folder": "/HN7/API/SC/demand_planning",
+  "testScript": {
+    "type": "PLAIN_TEXT",
+    "text": ""
+  },
+  "status": "Draft"
+}
+`, readStoreFile(t, dir, "testcases/OMNI-T1.json"))
+	assert.Equal(t, `{
+  "key": "OMNI-T2",
+  "name": "error case",
+  "projectKey": "OMNI",
+  "issueLinks": [
+    "OMNI-1"
+  ],
+  "objective": "",
+  "folder": "/HN7/API/SC/demand_planning",
+  "testScript": {
+    "type": "PLAIN_TEXT",
+    "text": ""
+  },
+  "status": "Draft"
+}
+`, readStoreFile(t, dir, "testcases/OMNI-T2.json"))
+	assert.Equal(t, `{
+  "key": "OMNI-C1",
+  "name": "[Demand planning][OMNI-1]GetDemandPlanning",
+  "projectKey": "OMNI",
+  "issueKey": "OMNI-1",
+  "plannedStartDate": "",
+  "plannedEndDate": "",
+  "folder": "/HN7/API/SC/demand_planning",
+  "items": [
+    {
+      "testCaseKey": "OMNI-T1",
+      "status": "Pass",
+      "executionTime": 1500
+    },
+    {
+      "testCaseKey": "OMNI-T2",
+      "status": "Fail",
+      "executionTime": 20,
+      "comment": "expected: 1, actual: 2"
+    }
+  ]
+}
+`, readStoreFile(t, dir, "cycles/OMNI-C1.json"))
+	assert.Equal(t, "request: {}\n", readStoreFile(t, dir, "attachments/OMNI-C1/OMNI-T2/OMNI-T2.log"))
+
+	tests, err := store.ListTests("OMNI-1")
+	assert.Nil(t, err)
+	assert.Equal(t, []*TestResult{
+		{Key: "OMNI-T1", Name: "happy case", Status: "Draft"},
+		{Key: "OMNI-T2", Name: "error case", Status: "Draft"},
+	}, tests)
+}
+
+func TestFileStore_CreateTestCycle(t *testing.T) {
+	dir := t.TempDir()
+	store := &FileStore{ProjectKey: fakeProjectKey, Dir: dir}
+	cycle := func(issueKey string, status string) *TestCycle {
+		return &TestCycle{IssueKey: issueKey, Folder: "/HN7/API/SC/demand_planning", Items: []*TestCycleItem{{
+			TestCaseKey: "OMNI-T1",
+			Status:      status,
+			Attachments: []*Attachment{{Name: status + ".log", Data: []byte(status)}},
+		}}}
+	}
+
+	key, err := store.CreateTestCycle(cycle("OMNI-1", "Fail"))
+	assert.Nil(t, err)
+	assert.Equal(t, "OMNI-C1", key)
+	// The next run of the issue overwrites its cycle
+	key, err = store.CreateTestCycle(cycle("OMNI-1", "Pass"))
+	assert.Nil(t, err)
+	assert.Equal(t, "OMNI-C1", key)
+	assert.Contains(t, readStoreFile(t, dir, "cycles/OMNI-C1.json"), `"status": "Pass"`)
+	assert.NoFileExists(t, filepath.Join(dir, "attachments/OMNI-C1/OMNI-T1/Fail.log"))
+	assert.Equal(t, "Pass", readStoreFile(t, dir, "attachments/OMNI-C1/OMNI-T1/Pass.log"))
+
+	key, err = store.CreateTestCycle(cycle("OMNI-2", "Pass"))
+	assert.Nil(t, err)
+	assert.Equal(t, "OMNI-C2", key)
+}
+
+func TestFileStore_UpdateMissingTest(t *testing.T) {
+	store := &FileStore{ProjectKey: fakeProjectKey, Dir: t.TempDir()}
+	err := store.UpdateTest("OMNI-T9", &TestCase{Name: "happy case"})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,262 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type (
+	// Fixtures are rows declared in YAML files, which map each table to its rows like a dataset.
+	// A row named by a _name key can be referenced from any row of the files: ${cate1.CategoryId}
+	// is the CategoryId of the row named cate1, with its type when it is the whole value, so a
+	// category path reads "${cate1.Path}/${cate2.CategoryId}". ${name} is the variable name given
+	// to LoadFixtures. The YAML merge keys share fields between the rows of a file.
+	Fixtures struct {
+		files   []string
+		dataset *jsonObject
+	}
+	fixtureLoader struct {
+		vars map[string]interface{}
+		rows map[string]*fixtureRow
+	}
+	fixtureRow struct {
+		label     string
+		fields    *jsonObject
+		resolved  map[string]interface{}
+		resolving map[string]bool
+	}
+)
+
+const fixtureNameKey = "_name"
+
+var fixtureReference = regexp.MustCompile(`\$\{([\w-]+)(?:\.(\w+))?\}`)
+
+// LoadFixtures reads the fixture files and resolves their references.
+func LoadFixtures(vars map[string]interface{}, files ...string) (*Fixtures, error) {
+	loader := &fixtureLoader{vars: vars, rows: make(map[string]*fixtureRow)}
+	fixtures := &Fixtures{files: files, dataset: &jsonObject{values: make(map[string]interface{})}}
+	var rows []*fixtureRow
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		node, err := decodeYAML(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		tables, ok := node.(*jsonObject)
+		if !ok {
+			return nil, fmt.Errorf("%s: fixtures map the tables to their rows", file)
+		}
+		for _, table := range tables.keys {
+			items, ok := tables.values[table].([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: the rows of %s are not a list", file, table)
+			}
+			if _, ok := fixtures.dataset.values[table]; !ok {
+				fixtures.dataset.keys = append(fixtures.dataset.keys, table)
+				fixtures.dataset.values[table] = []interface{}{}
+			}
+			for i, item := range items {
+				row, err := loader.add(file, table, i, item)
+				if err != nil {
+					return nil, err
+				}
+				rows = append(rows, row)
+				fixtures.dataset.values[table] = append(fixtures.dataset.values[table].([]interface{}), row)
+			}
+		}
+	}
+	// The rows are resolved once all the files are read, a row may reference a later one
+	for _, table := range fixtures.dataset.keys {
+		items := fixtures.dataset.values[table].([]interface{})
+		for i, item := range items {
+			resolved, err := loader.resolve(item.(*fixtureRow))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = resolved
+		}
+	}
+	return fixtures, nil
+}
+
+// Tables returns the tables of the fixtures, in the order of the files.
+func (f *Fixtures) Tables() []string {
+	return append([]string(nil), f.dataset.keys...)
+}
+
+// Rows returns the rows of the table of model, each one is a pointer to a new model.
+func (f *Fixtures) Rows(db *gorm.DB, model interface{}) ([]interface{}, error) {
+	sch, err := parseModel(db, model)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := datasetRows(f.dataset, sch)
+	if err != nil {
+		return nil, fmt.Errorf("fixtures of %s: %w", sch.Table, err)
+	}
+	items := make([]interface{}, 0, rows.Len())
+	for i := 0; i < rows.Len(); i++ {
+		items = append(items, rows.Index(i).Interface())
+	}
+	return items, nil
+}
+
+// Seed inserts the rows into the tables of models, in the order of models, like SeedDataset.
+func (f *Fixtures) Seed(db *gorm.DB, models ...interface{}) error {
+	return seedDataset(db, f.dataset, strings.Join(f.files, ", "), models...)
+}
+
+func (l *fixtureLoader) add(file string, table string, index int, item interface{}) (*fixtureRow, error) {
+	label := fmt.Sprintf("%s: %s[%d]", file, table, index)
+	fields, ok := item.(*jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an object", label)
+	}
+	row := &fixtureRow{
+		label:     label,
+		fields:    &jsonObject{values: make(map[string]interface{})},
+		resolved:  make(map[string]interface{}),
+		resolving: make(map[string]bool),
+	}
+	for _, key := range fields.keys {
+		if key != fixtureNameKey {
+			row.fields.keys = append(row.fields.keys, key)
+			row.fields.values[key] = fields.values[key]
+		}
+	}
+	name, ok := fields.values[fixtureNameKey]
+	if !ok {
+		return row, nil
+	}
+	if _, ok = name.(string); !ok || name == "" {
+		return nil, fmt.Errorf("%s: %s is not a name", label, fixtureNameKey)
+	}
+	if other, ok := l.rows[name.(string)]; ok {
+		return nil, fmt.Errorf("%s: %s is already the name of %s", label, name, other.label)
+	}
+	row.label = fmt.Sprintf("%s (%s)", label, name)
+	l.rows[name.(string)] = row
+	return row, nil
+}
+
+func (l *fixtureLoader) resolve(row *fixtureRow) (*jsonObject, error) {
+	resolved := &jsonObject{keys: row.fields.keys, values: make(map[string]interface{})}
+	for _, key := range row.fields.keys {
+		value, err := l.field(row, key)
+		if err != nil {
+			return nil, err
+		}
+		resolved.values[key] = value
+	}
+	return resolved, nil
+}
+
+func (l *fixtureLoader) field(row *fixtureRow, key string) (interface{}, error) {
+	if value, ok := row.resolved[key]; ok {
+		return value, nil
+	}
+	node, ok := row.fields.values[key]
+	if !ok {
+		return nil, fmt.Errorf("%s has no field %s", row.label, key)
+	}
+	if row.resolving[key] {
+		return nil, fmt.Errorf("%s: %s references itself", row.label, key)
+	}
+	row.resolving[key] = true
+	value, err := l.value(row, node)
+	delete(row.resolving, key)
+	if err != nil {
+		return nil, err
+	}
+	row.resolved[key] = value
+	return value, nil
+}
+
+func (l *fixtureLoader) value(row *fixtureRow, node interface{}) (interface{}, error) {
+	switch value := node.(type) {
+	case string:
+		return l.interpolate(row, value)
+	case *jsonObject:
+		object := &jsonObject{keys: value.keys, values: make(map[string]interface{})}
+		for _, key := range value.keys {
+			child, err := l.value(row, value.values[key])
+			if err != nil {
+				return nil, err
+			}
+			object.values[key] = child
+		}
+		return object, nil
+	case []interface{}:
+		array := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			child, err := l.value(row, item)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, child)
+		}
+		return array, nil
+	}
+	return node, nil
+}
+
+func (l *fixtureLoader) interpolate(row *fixtureRow, value string) (interface{}, error) {
+	matches := fixtureReference.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(value) {
+		return l.reference(row, value, matches[0])
+	}
+	var text strings.Builder
+	last := 0
+	for _, match := range matches {
+		referenced, err := l.reference(row, value, match)
+		if err != nil {
+			return nil, err
+		}
+		switch referenced := referenced.(type) {
+		case string:
+			text.WriteString(value[last:match[0]])
+			text.WriteString(referenced)
+		case json.Number, bool:
+			text.WriteString(value[last:match[0]])
+			text.WriteString(fmt.Sprint(referenced))
+		default:
+			return nil, fmt.Errorf("%s: %s is not a scalar", row.label, value[match[0]:match[1]])
+		}
+		last = match[1]
+	}
+	if len(matches) == 0 {
+		return value, nil
+	}
+	text.WriteString(value[last:])
+	return text.String(), nil
+}
+
+// reference returns the value of the reference at match in value.
+func (l *fixtureLoader) reference(row *fixtureRow, value string, match []int) (interface{}, error) {
+	name := value[match[2]:match[3]]
+	if match[4] < 0 {
+		variable, ok := l.vars[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown variable %s", row.label, name)
+		}
+		// The variables get the types of the values read from the files
+		content, err := json.Marshal(variable)
+		if err != nil {
+			return nil, fmt.Errorf("%s: variable %s: %w", row.label, name, err)
+		}
+		return decodeJSON(content)
+	}
+	target, ok := l.rows[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown row %s", row.label, name)
+	}
+	return l.field(target, value[match[4]:match[5]])
+}
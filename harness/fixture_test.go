@@ -0,0 +1,97 @@
+package harness
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type fixtureCategory struct {
+	Id          int64
+	SellerId    int32
+	CategoryId  int32
+	ParentId    sql.NullInt32
+	Path        sql.NullString
+	Name        string
+	MonthOfYear string
+}
+
+func (fixtureCategory) TableName() string {
+	return "monthly_category"
+}
+
+func writeFixture(t *testing.T, dir string, name string, content string) string {
+	file := filepath.Join(dir, name)
+	assert.Nil(t, os.WriteFile(file, []byte(content), 0644))
+	return file
+}
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	tree := writeFixture(t, dir, "tree.yaml", `
+monthly_category:
+  - &category
+    _name: cate1
+    SellerId: 1
+    CategoryId: 1
+    Path: ${cate1.CategoryId}
+    Name: Category ${cate1.CategoryId}
+    MonthOfYear: ${monthOfYear}
+  - <<: *category
+    _name: cate2
+    CategoryId: 2
+    ParentId: ${cate1.CategoryId}
+    Path: ${cate1.Path}/${cate2.CategoryId}
+    Name: Category 2
+`)
+	leaves := writeFixture(t, dir, "leaves.yaml", `
+monthly_category:
+  - _name: cate5
+    SellerId: ${cate2.SellerId}
+    CategoryId: 5
+    parent_id: ${cate2.CategoryId}
+    path: ${cate2.Path}/${cate5.CategoryId}
+    MonthOfYear: ${monthOfYear}
+`)
+	fixtures, err := LoadFixtures(map[string]interface{}{"monthOfYear": "2023-11"}, tree, leaves)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"monthly_category"}, fixtures.Tables())
+
+	db := &gorm.DB{Config: &gorm.Config{NamingStrategy: schema.NamingStrategy{SingularTable: true}}}
+	rows, err := fixtures.Rows(db, &fixtureCategory{})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		&fixtureCategory{SellerId: 1, CategoryId: 1, Path: sql.NullString{String: "1", Valid: true}, Name: "Category 1", MonthOfYear: "2023-11"},
+		&fixtureCategory{SellerId: 1, CategoryId: 2, ParentId: sql.NullInt32{Int32: 1, Valid: true}, Path: sql.NullString{String: "1/2", Valid: true}, Name: "Category 2", MonthOfYear: "2023-11"},
+		&fixtureCategory{SellerId: 1, CategoryId: 5, ParentId: sql.NullInt32{Int32: 2, Valid: true}, Path: sql.NullString{String: "1/2/5", Valid: true}, MonthOfYear: "2023-11"},
+	}, rows)
+
+	rows, err = fixtures.Rows(db, &driftSupplier{})
+	assert.Nil(t, err)
+	assert.Empty(t, rows)
+	assert.EqualError(t, fixtures.Seed(db, &driftSupplier{}), tree+", "+leaves+": table monthly_category has no model")
+}
+
+func TestLoadFixtures_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for content, message := range map[string]string{
+		"monthly_category:\n  - CategoryId: ${cate9.CategoryId}\n":                                  "unknown row cate9",
+		"monthly_category:\n  - MonthOfYear: ${month}\n":                                            "unknown variable month",
+		"monthly_category:\n  - _name: a\n    Path: ${a.Path}\n":                                    "Path references itself",
+		"monthly_category:\n  - _name: a\n    Path: ${b.Path}\n  - _name: b\n    Path: ${a.Path}\n": "Path references itself",
+		"monthly_category:\n  - _name: a\n  - _name: a\n":                                           "a is already the name of",
+		"monthly_category:\n  - _name: a\n    Tags: [1]\n  - Name: tag ${a.Tags}\n":                 "${a.Tags} is not a scalar",
+		"monthly_category:\n  - _name: a\n    CategoryId: 1\n  - ParentId: ${a.ParentId}\n":         "has no field ParentId",
+		"monthly_category: {}\n": "the rows of monthly_category are not a list",
+	} {
+		_, err := LoadFixtures(nil, writeFixture(t, dir, "fixture.yaml", content))
+		if assert.NotNil(t, err, content) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+}
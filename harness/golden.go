@@ -0,0 +1,350 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebdah/goldie/v2"
+)
+
+type (
+	// GoldenNormalizer replaces the values which change from one run to another, such as ids,
+	// timestamps and trace ids, with placeholders before comparing with a golden file. The
+	// values are selected by Fields, by a golden struct tag naming the placeholder, for example
+	// `golden:"any-time"`, or by the placeholders written in the golden file itself. A value is
+	// only replaced when it fits the placeholder, a zero time does not match <any-time>.
+	GoldenNormalizer struct {
+		// Fields maps a JSON path, such as "Resp.trace_id" or "Items.*.CreatedAt", to its
+		// placeholder. A path without a dot matches the key at any depth.
+		Fields map[string]string
+	}
+	// jsonObject is a JSON object which keeps the order of its keys, so the golden files keep
+	// the order of the struct fields.
+	jsonObject struct {
+		keys   []string
+		values map[string]interface{}
+	}
+)
+
+const (
+	AnyTime  = "<any-time>"
+	AnyID    = "<any-id>"
+	AnyValue = "<any>"
+
+	goldenTag = "golden"
+)
+
+var (
+	placeholders = []string{AnyTime, AnyID, AnyValue}
+	// ModelGoldenFields are the columns set by the database and gorm
+	ModelGoldenFields = map[string]string{
+		"Id":         AnyID,
+		"CreatedAt":  AnyTime,
+		"UpdatedAt":  AnyTime,
+		"ExecutedAt": AnyTime,
+	}
+)
+
+// AssertJson normalizes actual then compares it with the golden file name of g, like
+// g.AssertJson does. The placeholders of the golden file are kept on -update when the
+// values still fit them.
+func (n GoldenNormalizer) AssertJson(t *testing.T, g *goldie.Goldie, name string, actual interface{}) {
+	t.Helper()
+	content, err := n.normalize(actual, g.GoldenFileName(t, name))
+	if err != nil {
+		t.Fatalf("could not normalize %s: %v", name, err)
+	}
+	g.Assert(t, name, content)
+}
+
+func (n GoldenNormalizer) normalize(actual interface{}, goldenFile string) ([]byte, error) {
+	content, err := json.Marshal(actual)
+	if err != nil {
+		return nil, err
+	}
+	node, err := decodeJSON(content)
+	if err != nil {
+		return nil, err
+	}
+	node = n.replaceFields(node, nil)
+	node = replaceTagged(reflect.ValueOf(actual), node)
+	if golden, err := os.ReadFile(goldenFile); err == nil {
+		if expected, err := decodeJSON(golden); err == nil {
+			node = replaceLikeGolden(expected, node)
+		}
+	}
+	content, err = json.MarshalIndent(node, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// The placeholders stay readable in the golden files
+	for _, placeholder := range placeholders {
+		escaped, _ := json.Marshal(placeholder)
+		content = bytes.ReplaceAll(content, escaped, []byte(strconv.Quote(placeholder)))
+	}
+	return content, nil
+}
+
+func (n GoldenNormalizer) replaceFields(node interface{}, path []string) interface{} {
+	switch value := node.(type) {
+	case *jsonObject:
+		for _, key := range value.keys {
+			value.values[key] = n.replaceField(value.values[key], append(path, key))
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = n.replaceField(value[i], append(path, strconv.Itoa(i)))
+		}
+	}
+	return node
+}
+
+func (n GoldenNormalizer) replaceField(node interface{}, path []string) interface{} {
+	if placeholder := n.placeholder(path); placeholder != "" && fitsPlaceholder(placeholder, node) {
+		return placeholder
+	}
+	return n.replaceFields(node, path)
+}
+
+// placeholder returns the placeholder of the first field, in name order, matching path.
+func (n GoldenNormalizer) placeholder(path []string) string {
+	fields := make([]string, 0, len(n.Fields))
+	for field := range n.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if matchPath(strings.Split(field, "."), path) {
+			return n.Fields[field]
+		}
+	}
+	return ""
+}
+
+func matchPath(selector []string, path []string) bool {
+	if len(selector) == 1 {
+		return len(path) > 0 && path[len(path)-1] == selector[0]
+	}
+	if len(selector) != len(path) {
+		return false
+	}
+	for i := range selector {
+		if selector[i] != "*" && selector[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// replaceTagged replaces the fields of value tagged with a placeholder, node is value as JSON.
+func replaceTagged(value reflect.Value, node interface{}) interface{} {
+	// A dataset holds the tables as values of an ordered object
+	if value.IsValid() && value.CanInterface() {
+		if holder, ok := value.Interface().(*jsonObject); ok && holder != nil {
+			if object, ok := node.(*jsonObject); ok {
+				for _, key := range object.keys {
+					object.values[key] = replaceTagged(reflect.ValueOf(holder.values[key]), object.values[key])
+				}
+			}
+			return node
+		}
+	}
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return node
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		object, ok := node.(*jsonObject)
+		if !ok {
+			return node
+		}
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name, named := jsonName(field)
+			if name == "-" {
+				continue
+			}
+			if field.Anonymous && !named {
+				replaceTagged(value.Field(i), object)
+				continue
+			}
+			child, ok := object.values[name]
+			if !ok {
+				continue
+			}
+			if tag := field.Tag.Get(goldenTag); tag != "" && fitsPlaceholder("<"+tag+">", child) {
+				object.values[name] = "<" + tag + ">"
+				continue
+			}
+			object.values[name] = replaceTagged(value.Field(i), child)
+		}
+	case reflect.Slice, reflect.Array:
+		array, ok := node.([]interface{})
+		if !ok {
+			return node
+		}
+		for i := 0; i < value.Len() && i < len(array); i++ {
+			array[i] = replaceTagged(value.Index(i), array[i])
+		}
+	case reflect.Map:
+		object, ok := node.(*jsonObject)
+		if !ok || value.Type().Key().Kind() != reflect.String {
+			return node
+		}
+		for _, key := range object.keys {
+			item := value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key()))
+			if item.IsValid() {
+				object.values[key] = replaceTagged(item, object.values[key])
+			}
+		}
+	}
+	return node
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name, false
+	}
+	return name, true
+}
+
+// replaceLikeGolden replaces the values of actual found where the golden file has a placeholder.
+func replaceLikeGolden(expected interface{}, actual interface{}) interface{} {
+	switch value := expected.(type) {
+	case string:
+		if isPlaceholder(value) && fitsPlaceholder(value, actual) {
+			return value
+		}
+	case *jsonObject:
+		if object, ok := actual.(*jsonObject); ok {
+			for _, key := range object.keys {
+				if child, ok := value.values[key]; ok {
+					object.values[key] = replaceLikeGolden(child, object.values[key])
+				}
+			}
+		}
+	case []interface{}:
+		if array, ok := actual.([]interface{}); ok {
+			for i := 0; i < len(array) && i < len(value); i++ {
+				array[i] = replaceLikeGolden(value[i], array[i])
+			}
+		}
+	}
+	return actual
+}
+
+func isPlaceholder(value string) bool {
+	return contains(placeholders, value)
+}
+
+func fitsPlaceholder(placeholder string, node interface{}) bool {
+	switch placeholder {
+	case AnyTime:
+		value, ok := node.(string)
+		if !ok {
+			return false
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil && !parsed.IsZero()
+	case AnyID:
+		switch value := node.(type) {
+		case json.Number:
+			id, err := value.Int64()
+			return err == nil && id > 0
+		case string:
+			return value != ""
+		}
+		return false
+	}
+	return true
+}
+
+// decodeJSON decodes content keeping the order of the object keys and the numbers as written.
+func decodeJSON(content []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	node, err := decodeNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected content after the JSON value")
+	}
+	return node, nil
+}
+
+func decodeNode(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := &jsonObject{values: make(map[string]interface{})}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.keys = append(object.keys, key.(string))
+			object.values[key.(string)] = value
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := make([]interface{}, 0)
+		for decoder.More() {
+			value, err := decodeNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,125 @@
+package harness
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	goldenItem struct {
+		Id        int64
+		Sku       string
+		CreatedAt time.Time
+		DeletedAt time.Time
+	}
+	goldenResponse struct {
+		TraceId string `json:"trace_id,omitempty"`
+		Code    int
+	}
+	goldenRun struct {
+		Resp       *goldenResponse
+		Items      []*goldenItem
+		ExecutedBy string `golden:"any"`
+		Note       string
+	}
+)
+
+func newGoldenRun(id int64, traceID string) goldenRun {
+	createdAt := time.Now()
+	return goldenRun{
+		Resp: &goldenResponse{TraceId: traceID, Code: 200},
+		Items: []*goldenItem{
+			{Id: id, Sku: "sku <1>", CreatedAt: createdAt},
+			{Id: 0, Sku: "sku2", CreatedAt: createdAt},
+		},
+		ExecutedBy: traceID,
+		Note:       "nightly",
+	}
+}
+
+func TestGoldenNormalizer_Normalize(t *testing.T) {
+	normalizer := GoldenNormalizer{Fields: map[string]string{
+		"Id":            AnyID,
+		"CreatedAt":     AnyTime,
+		"DeletedAt":     AnyTime,
+		"Resp.trace_id": AnyValue,
+	}}
+	content, err := normalizer.normalize(newGoldenRun(12, "trace-1"), filepath.Join(t.TempDir(), "missing.golden"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{
+  "Resp": {
+    "trace_id": "<any>",
+    "Code": 200
+  },
+  "Items": [
+    {
+      "Id": "<any-id>",
+      "Sku": "sku \u003c1\u003e",
+      "CreatedAt": "<any-time>",
+      "DeletedAt": "0001-01-01T00:00:00Z"
+    },
+    {
+      "Id": 0,
+      "Sku": "sku2",
+      "CreatedAt": "<any-time>",
+      "DeletedAt": "0001-01-01T00:00:00Z"
+    }
+  ],
+  "ExecutedBy": "<any>",
+  "Note": "nightly"
+}`, string(content))
+}
+
+func TestGoldenNormalizer_GoldenPlaceholders(t *testing.T) {
+	goldenFile := filepath.Join(t.TempDir(), "run.golden")
+	assert.Nil(t, os.WriteFile(goldenFile, []byte(`{"Items": [{"Id": "<any-id>", "Sku": "<any-id>"}, {"Id": "<any-id>"}], "Note": "<any>"}`), 0644))
+
+	content, err := GoldenNormalizer{}.normalize(newGoldenRun(7, "trace-2"), goldenFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), `"Id": "<any-id>",
+      "Sku": "<any-id>"`)
+	// A zero id does not fit the placeholder
+	assert.Contains(t, string(content), `"Id": 0,`)
+	assert.Contains(t, string(content), `"Note": "<any>"`)
+}
+
+func TestGoldenNormalizer_AssertJson(t *testing.T) {
+	normalizer := GoldenNormalizer{Fields: map[string]string{"trace_id": AnyValue}}
+	for field, placeholder := range ModelGoldenFields {
+		normalizer.Fields[field] = placeholder
+	}
+	g := goldie.New(t, goldie.WithFixtureDir(t.TempDir()))
+
+	updateGolden(t)
+	normalizer.AssertJson(t, g, "run", newGoldenRun(1, "trace-3"))
+	assert.Nil(t, flag.Set("update", "false"))
+
+	normalizer.AssertJson(t, g, "run", newGoldenRun(2, "trace-4"))
+}
+
+// updateGolden turns on the -update flag of goldie, its value is put back once t is done even
+// when an assertion stops it.
+func updateGolden(t *testing.T) {
+	previous := flag.Lookup("update").Value.String()
+	t.Cleanup(func() {
+		_ = flag.Set("update", previous)
+	})
+	assert.Nil(t, flag.Set("update", "true"))
+}
+
+func TestDecodeJSON(t *testing.T) {
+	node, err := decodeJSON([]byte(`{"b": 1.50, "a": [true, null, {}], "c": "x"}`))
+	assert.Nil(t, err)
+	content, err := node.(*jsonObject).MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"b":1.50,"a":[true,null,{}],"c":"x"}`, string(content))
+
+	_, err = decodeJSON([]byte(`{} {}`))
+	assert.NotNil(t, err)
+}
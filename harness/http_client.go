@@ -0,0 +1,188 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type (
+	// RequestError is returned when a test management backend answers with a non 2xx status.
+	RequestError struct {
+		Method     string
+		Url        string
+		StatusCode int
+		Body       string
+	}
+	// PushError aggregates every failure of one PushTests call.
+	PushError struct {
+		IssueKey string
+		Errs     []error
+	}
+	// HttpClientConfig tunes the retries and rate limit of a backend client.
+	// Zero values fall back to the defaults below, NoRetry turns the retries off.
+	HttpClientConfig struct {
+		RetryCount    int
+		RetryWaitTime time.Duration
+		RateLimit     int
+	}
+	rateLimiter struct {
+		mu       sync.Mutex
+		interval time.Duration
+		next     time.Time
+	}
+)
+
+const (
+	// NoRetry as RetryCount sends every request once
+	NoRetry = -1
+
+	requestTimeout          = 10 * time.Second
+	defaultRetryCount       = 3
+	defaultRetryWaitTime    = 500 * time.Millisecond
+	defaultRetryMaxWaitTime = 5 * time.Second
+	// defaultRateLimit is the number of requests per second sent by one client
+	defaultRateLimit = 5
+)
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Url, e.StatusCode, e.Body)
+}
+
+func (e *PushError) Error() string {
+	messages := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("push tests of %s failed with %d error(s): %s", e.IssueKey, len(e.Errs), strings.Join(messages, "; "))
+}
+
+func (e *PushError) Unwrap() []error {
+	return e.Errs
+}
+
+func (e *PushError) add(err error) {
+	if err != nil {
+		e.Errs = append(e.Errs, err)
+	}
+}
+
+func (e *PushError) errOrNil() error {
+	if len(e.Errs) == 0 {
+		return nil
+	}
+	return e
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	return &rateLimiter{interval: time.Second / time.Duration(perSecond)}
+}
+
+// Wait blocks until the next request slot is free.
+func (l *rateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+	time.Sleep(wait)
+}
+
+// newHttpClient builds a resty client which retries the failed requests with backoff, see
+// retryRequest, and never sends more than cfg.RateLimit requests per second.
+func newHttpClient(cfg HttpClientConfig) *resty.Client {
+	if cfg.RetryCount == 0 {
+		cfg.RetryCount = defaultRetryCount
+	} else if cfg.RetryCount < 0 {
+		cfg.RetryCount = 0
+	}
+	if cfg.RetryWaitTime == 0 {
+		cfg.RetryWaitTime = defaultRetryWaitTime
+	}
+	if cfg.RateLimit == 0 {
+		cfg.RateLimit = defaultRateLimit
+	}
+	limiter := newRateLimiter(cfg.RateLimit)
+	client := resty.New()
+	client.SetTimeout(requestTimeout)
+	client.SetRetryCount(cfg.RetryCount)
+	client.SetRetryWaitTime(cfg.RetryWaitTime)
+	client.SetRetryMaxWaitTime(defaultRetryMaxWaitTime)
+	client.SetRetryAfter(retryAfter)
+	client.AddRetryCondition(retryRequest)
+	client.OnBeforeRequest(func(*resty.Client, *resty.Request) error {
+		limiter.Wait()
+		return nil
+	})
+	return client
+}
+
+// retryRequest retries the idempotent requests on transport errors, 429 and 5xx responses.
+// The other requests create tests, cycles and folders, so they are only retried on a 429 or
+// when they never reached the server.
+func retryRequest(res *resty.Response, err error) bool {
+	if res == nil || res.Request == nil {
+		return false
+	}
+	idempotent := isIdempotent(res.Request.Method)
+	if err != nil {
+		return idempotent || neverSent(err)
+	}
+	if res.StatusCode() == http.StatusTooManyRequests {
+		return true
+	}
+	return idempotent && res.StatusCode() >= http.StatusInternalServerError
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// neverSent tells whether err happened while connecting, before any byte of the request was sent.
+func neverSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryAfter honours the Retry-After header of a 429, otherwise resty uses its exponential backoff.
+func retryAfter(_ *resty.Client, res *resty.Response) (time.Duration, error) {
+	if res == nil || res.StatusCode() != http.StatusTooManyRequests {
+		return 0, nil
+	}
+	seconds, err := strconv.Atoi(res.Header().Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0, nil
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// execute sends the request and turns non 2xx responses into a RequestError.
+func execute(req *resty.Request, method string, url string) (*resty.Response, error) {
+	res, err := req.Execute(method, url)
+	if err != nil {
+		return res, err
+	}
+	if res.IsError() {
+		return res, &RequestError{
+			Method:     method,
+			Url:        url,
+			StatusCode: res.StatusCode(),
+			Body:       strings.TrimSpace(res.String()),
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,237 @@
+package harness
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type (
+	IssueManagerService interface {
+		GetIssue(issueKey string) (*Issue, error)
+		ListTests(issueKey string) ([]*TestResult, error)
+		CreateTest(testCase *TestCase) (string, error)
+		UpdateTest(testKey string, testCase *TestCase) error
+		DeleteTest(testKey string) error
+		CreateTestCycle(cycle *TestCycle) (string, error)
+		DeleteTestCycle(cycleKey string) error
+		CreateFolder(folder string) error
+	}
+	Jira struct {
+		HttpClientConfig
+		ProjectKey string
+		UserName   string
+		Password   string
+		// Token is a personal access token, it is preferred over UserName and Password
+		Token    string
+		Url      string
+		client   *resty.Client
+		clientMu sync.Mutex
+	}
+	CreateResult struct {
+		Key string `json:"key"`
+	}
+	GetIssueFieldsStatus struct {
+		Name string `json:"name"`
+	}
+	GetIssueFieldsParent struct {
+		Key string `json:"key"`
+	}
+	GetIssueFields struct {
+		Status   GetIssueFieldsStatus  `json:"status"`
+		Parent   *GetIssueFieldsParent `json:"parent"`
+		EpicName string                `json:"customfield_10001"`
+	}
+	GetIssueResult struct {
+		Fields GetIssueFields `json:"fields"`
+	}
+	TestRunResult struct {
+		Id          int    `json:"id"`
+		TestCaseKey string `json:"testCaseKey"`
+	}
+)
+
+func (j *Jira) GetClient() *resty.Client {
+	j.clientMu.Lock()
+	defer j.clientMu.Unlock()
+	client := j.client
+	if client == nil {
+		client = j.initClient()
+		j.client = client
+	}
+	return client
+}
+
+func (j *Jira) initClient() *resty.Client {
+	client := newHttpClient(j.HttpClientConfig)
+	testLog.AddSecret(j.Password)
+	testLog.AddSecret(j.Token)
+	if j.Token != "" {
+		client.SetAuthToken(j.Token)
+	} else {
+		client.SetBasicAuth(j.UserName, j.Password)
+	}
+	return client
+}
+
+func (j *Jira) GetIssue(issueKey string) (*Issue, error) {
+	url := j.Url + ""
+	url = fmt.Sprintf("%s/rest/api/2/issue/%s?fields=status,parent,customfield_10001", url, issueKey)
+	client := j.GetClient()
+	res, err := execute(client.R().SetResult(&GetIssueResult{}), resty.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+
+	issueRawFields := res.Result().(*GetIssueResult).Fields
+	testLog.Info("get issue", "issueKey", issueKey, "status", issueRawFields.Status.Name, "epic", issueRawFields.EpicName)
+	issue := &Issue{
+		Key:    issueKey,
+		Status: issueRawFields.Status.Name,
+		Epic:   issueRawFields.EpicName,
+	}
+	if issueRawFields.Parent != nil {
+		parent, err := j.GetIssue(issueRawFields.Parent.Key)
+		if err != nil {
+			return nil, err
+		}
+		issue.Epic = parent.Epic
+	}
+	return issue, nil
+}
+
+func (j *Jira) ListTests(issueKey string) ([]*TestResult, error) {
+	url := j.Url + "/rest/atm/1.0/testcase/search"
+	client := j.GetClient()
+	query := fmt.Sprintf("projectKey = \"%s\" AND issueKeys IN (%s)", j.ProjectKey, issueKey)
+	res, err := execute(client.R().SetQueryParam("query", query).SetResult([]*TestResult{}), resty.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+	tests := *res.Result().(*[]*TestResult)
+	testLog.Info("list tests", "issueKey", issueKey, "query", query, "count", len(tests))
+	return tests, nil
+}
+
+func (j *Jira) CreateTest(testCase *TestCase) (string, error) {
+	url := j.Url + "/rest/atm/1.0/testcase"
+	testCase.ProjectKey = j.ProjectKey
+	client := j.GetClient()
+	req := client.R().
+		SetBody(testCase).
+		SetResult(&CreateResult{})
+	resp, err := execute(req, resty.MethodPost, url)
+	if err != nil {
+		return "", err
+	}
+	return resp.Result().(*CreateResult).Key, nil
+}
+
+func (j *Jira) UpdateTest(testKey string, testCase *TestCase) error {
+	url := fmt.Sprintf("%s/rest/atm/1.0/testcase/%s", j.Url, testKey)
+	testCase.ProjectKey = j.ProjectKey
+	client := j.GetClient()
+	_, err := execute(client.R().SetBody(testCase), resty.MethodPut, url)
+	return err
+}
+
+func (j *Jira) DeleteTest(testKey string) error {
+	url := j.Url + "/rest/atm/1.0/testcase"
+	url = fmt.Sprintf("%s/%s", url, testKey)
+	client := j.GetClient()
+	_, err := execute(client.R(), resty.MethodDelete, url)
+	return err
+}
+
+func (j *Jira) CreateTestCycle(cycle *TestCycle) (string, error) {
+	url := j.Url + "/rest/atm/1.0/testrun"
+	cycle.ProjectKey = j.ProjectKey
+	client := j.GetClient()
+	req := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(cycle).
+		SetResult(&CreateResult{})
+	resp, err := execute(req, resty.MethodPost, url)
+	if err != nil {
+		return "", err
+	}
+	cycleKey := resp.Result().(*CreateResult).Key
+	return cycleKey, j.uploadAttachments(cycleKey, cycle.Items)
+}
+
+// uploadAttachments attaches the files of each item to its test result in the cycle.
+func (j *Jira) uploadAttachments(cycleKey string, items []*TestCycleItem) error {
+	hasAttachment := false
+	for _, item := range items {
+		hasAttachment = hasAttachment || len(item.Attachments) > 0
+	}
+	if !hasAttachment {
+		return nil
+	}
+	client := j.GetClient()
+	url := fmt.Sprintf("%s/rest/atm/1.0/testrun/%s/testresults", j.Url, cycleKey)
+	res, err := execute(client.R().SetResult([]*TestRunResult{}), resty.MethodGet, url)
+	if err != nil {
+		return err
+	}
+	resultIds := make(map[string]int)
+	for _, result := range *res.Result().(*[]*TestRunResult) {
+		resultIds[result.TestCaseKey] = result.Id
+	}
+	for _, item := range items {
+		resultId, ok := resultIds[item.TestCaseKey]
+		if !ok {
+			continue
+		}
+		for _, attachment := range item.Attachments {
+			url = fmt.Sprintf("%s/rest/atm/1.0/testresult/%d/attachments", j.Url, resultId)
+			req := client.R().SetFileReader("file", attachment.Name, bytes.NewReader(attachment.Data))
+			if _, err = execute(req, resty.MethodPost, url); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (j *Jira) DeleteTestCycle(cycleKey string) error {
+	url := j.Url + "/rest/atm/1.0/testrun"
+	url = fmt.Sprintf("%s/%s", url, cycleKey)
+	client := j.GetClient()
+	_, err := execute(client.R(), resty.MethodDelete, url)
+	return err
+}
+
+// CreateFolder creates the test case and test run folders, folders created by a previous run are kept.
+func (j *Jira) CreateFolder(folder string) error {
+	url := j.Url + "/rest/atm/1.0/folder"
+	client := j.GetClient()
+	payload := &Folder{
+		ProjectKey: j.ProjectKey,
+		Name:       folder,
+	}
+	for _, folderType := range []string{"TEST_CASE", "TEST_RUN"} {
+		payload.Type = folderType
+		req := client.R().
+			SetHeader("Content-Type", "application/json").
+			SetBody(payload).
+			SetResult(&CreateResult{})
+		_, err := execute(req, resty.MethodPost, url)
+		if err != nil && !isFolderExistsError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func isFolderExistsError(err error) bool {
+	var reqErr *RequestError
+	return errors.As(err, &reqErr) &&
+		reqErr.StatusCode == http.StatusBadRequest &&
+		strings.Contains(strings.ToLower(reqErr.Body), "already exist")
+}
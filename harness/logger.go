@@ -0,0 +1,97 @@
+package harness
+
+import (
+	ctx "context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.tekoapis.com/kitchen/log/level"
+)
+
+// redactLogger masks credentials, both the values of sensitive keys and any registered
+// secret found in a message or value, before handing the line to the kitchen logger.
+type redactLogger struct {
+	mu       sync.Mutex
+	logInfo  func(line string)
+	logError func(line string)
+	secrets  []string
+}
+
+const redacted = "***"
+
+var (
+	sensitiveKeys = []string{"password", "token", "secret", "authorization", "apikey"}
+	testLog       = newRedactLogger(
+		func(line string) { level.Info(ctx.Background()).F("%s", line) },
+		func(line string) { level.Error(ctx.Background()).F("%s", line) },
+	)
+)
+
+func newRedactLogger(logInfo func(line string), logError func(line string)) *redactLogger {
+	return &redactLogger{logInfo: logInfo, logError: logError}
+}
+
+// AddSecret makes the logger mask secret wherever it appears.
+func (l *redactLogger) AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.secrets {
+		if s == secret {
+			return
+		}
+	}
+	l.secrets = append(l.secrets, secret)
+}
+
+func (l *redactLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.logInfo(l.format(msg, keysAndValues))
+}
+
+func (l *redactLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.logError(l.format(msg, append(keysAndValues, "error", err)))
+}
+
+// format writes msg followed by the logfmt pairs of keysAndValues.
+func (l *redactLogger) format(msg string, keysAndValues []interface{}) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var b strings.Builder
+	b.WriteString(l.redact(msg))
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		value := redacted
+		if i+1 < len(keysAndValues) && !isSensitiveKey(key) {
+			value = l.redact(fmt.Sprint(keysAndValues[i+1]))
+		}
+		fmt.Fprintf(&b, " %s=%s", key, quote(value))
+	}
+	return b.String()
+}
+
+func (l *redactLogger) redact(s string) string {
+	for _, secret := range l.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,267 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	migrateV4 "github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+type (
+	// MigrationSuite applies the migrations of the service one at a time on an empty database
+	// and checks that rolling each of them back restores the previous schema, and that
+	// applying it again gives the same schema. Run it with suite.Run from the service tests.
+	MigrationSuite struct {
+		suite.Suite
+		// Irreversible lists the versions allowed to have no down migration
+		Irreversible []uint
+		// Registry is checked against the fully migrated schema by TestModelDrift
+		Registry  ModelRegistry
+		dir       string
+		env       *MySQLEnv
+		admin     *gorm.DB
+		db        *gorm.DB
+		container testcontainers.Container
+	}
+	// migrationFile is one version of the migrations directory.
+	migrationFile struct {
+		Version uint
+		Name    string
+		Up      string
+		Down    string
+	}
+	// schemaSnapshot is the information_schema description of a database, one sorted line
+	// per table, column, index and constraint.
+	schemaSnapshot []string
+)
+
+const (
+	migrationCheckDatabase = "migration_check"
+	migrationTable         = "schema_migrations"
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.*)\.(up|down)\.sql$`)
+
+func (s *MigrationSuite) SetupSuite() {
+	ctx := context.Background()
+	dir, err := findMigrationDir()
+	s.Require().Nil(err)
+	s.dir = dir
+	if IsLocalEnv() {
+		s.env, err = LoadLocalMySQLEnv(migrationCheckDatabase)
+	} else {
+		s.container, s.env, err = startMySqlContainer(ctx, "")
+	}
+	s.Require().Nil(err)
+
+	// The migrations run on their own empty database, next to the one of the tests
+	server := *s.env
+	server.Database = ""
+	s.admin, err = gorm.Open(mysql.Open(server.DSN()), &gorm.Config{Logger: gormLogger.Discard})
+	s.Require().Nil(err)
+	s.env.Database = cloneName(migrationCheckDatabase, os.Getpid(), 0)
+	s.Require().Nil(s.admin.Exec(fmt.Sprintf("CREATE DATABASE `%s`", s.env.Database)).Error)
+	s.db, err = gorm.Open(mysql.Open(s.env.DSN()), &gorm.Config{Logger: gormLogger.Discard})
+	s.Require().Nil(err)
+}
+
+func (s *MigrationSuite) TearDownSuite() {
+	if s.db != nil {
+		if sqlDB, err := s.db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
+	if s.admin != nil {
+		s.Nil(s.admin.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", s.env.Database)).Error)
+		if sqlDB, err := s.admin.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
+	if s.container != nil {
+		s.Nil(s.container.Terminate(context.Background()))
+	}
+}
+
+func (s *MigrationSuite) TestRoundTrip() {
+	files, err := readMigrationFiles(s.dir)
+	s.Require().Nil(err)
+	s.Require().NotEmpty(files, "no migration found in %s", s.dir)
+	migrate, err := migrateV4.New(fmt.Sprintf("file://%s", s.dir), s.env.URL())
+	s.Require().Nil(err)
+	defer migrate.Close()
+
+	for _, file := range files {
+		before, err := s.snapshot()
+		s.Require().Nil(err)
+		s.Require().Nil(migrate.Steps(1), "%d_%s: up", file.Version, file.Name)
+		after, err := s.snapshot()
+		s.Require().Nil(err)
+
+		if strings.TrimSpace(file.Down) == "" {
+			if !s.isIrreversible(file.Version) {
+				s.Failf("irreversible migration", "%d_%s has no down migration", file.Version, file.Name)
+			}
+			continue
+		}
+		if err = migrate.Steps(-1); err != nil {
+			s.Failf("irreversible migration", "%d_%s: down: %v", file.Version, file.Name, err)
+			// Leave the schema as the up migration left it and carry on with the next versions
+			s.Require().Nil(migrate.Force(int(file.Version)))
+			continue
+		}
+		rolledBack, err := s.snapshot()
+		s.Require().Nil(err)
+		if diff := before.diff(rolledBack); diff != "" {
+			s.Failf("down migration does not restore the schema", "%d_%s:\n%s", file.Version, file.Name, diff)
+		}
+
+		s.Require().Nil(migrate.Steps(1), "%d_%s: up after down", file.Version, file.Name)
+		reapplied, err := s.snapshot()
+		s.Require().Nil(err)
+		if diff := after.diff(reapplied); diff != "" {
+			s.Failf("non idempotent migration", "%d_%s gives another schema once rolled back and applied again:\n%s", file.Version, file.Name, diff)
+		}
+	}
+}
+
+// TestModelDrift applies every migration on a database of its own, next to the one of
+// TestRoundTrip, and checks Registry against it.
+func (s *MigrationSuite) TestModelDrift() {
+	if len(s.Registry.Models) == 0 {
+		s.T().Skip("no registered model")
+	}
+	env := *s.env
+	env.Database = cloneName(migrationCheckDatabase, os.Getpid(), 1)
+	s.Require().Nil(s.admin.Exec(fmt.Sprintf("CREATE DATABASE `%s`", env.Database)).Error)
+	defer func() {
+		s.Nil(s.admin.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", env.Database)).Error)
+	}()
+	migrate, err := migrateV4.New(fmt.Sprintf("file://%s", s.dir), env.URL())
+	s.Require().Nil(err)
+	defer migrate.Close()
+	s.Require().Nil(migrate.Up())
+
+	db, err := gorm.Open(mysql.Open(env.DSN()), &gorm.Config{Logger: gormLogger.Discard})
+	s.Require().Nil(err)
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+	s.Registry.Check(s.T(), db)
+}
+
+func (s *MigrationSuite) isIrreversible(version uint) bool {
+	for _, v := range s.Irreversible {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot describes the tables, columns, indexes and foreign keys of the database, except
+// the table of the migration tool.
+func (s *MigrationSuite) snapshot() (schemaSnapshot, error) {
+	queries := []string{
+		`SELECT CONCAT_WS(' ', 'table', TABLE_NAME, ENGINE, TABLE_COLLATION)
+		FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME <> ?`,
+		`SELECT CONCAT_WS(' ', 'column', TABLE_NAME, COLUMN_NAME, ORDINAL_POSITION, COLUMN_TYPE, IS_NULLABLE,
+			IFNULL(COLUMN_DEFAULT, 'NULL'), EXTRA, IFNULL(COLLATION_NAME, ''))
+		FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME <> ?`,
+		`SELECT CONCAT_WS(' ', 'index', TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX, COLUMN_NAME, NON_UNIQUE)
+		FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = ? AND TABLE_NAME <> ?`,
+		`SELECT CONCAT_WS(' ', 'constraint', TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME,
+			IFNULL(REFERENCED_TABLE_NAME, ''), IFNULL(REFERENCED_COLUMN_NAME, ''))
+		FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = ? AND TABLE_NAME <> ?`,
+	}
+	var snapshot schemaSnapshot
+	for _, query := range queries {
+		var lines []string
+		if err := s.db.Raw(query, s.env.Database, migrationTable).Scan(&lines).Error; err != nil {
+			return nil, err
+		}
+		snapshot = append(snapshot, lines...)
+	}
+	sort.Strings(snapshot)
+	return snapshot, nil
+}
+
+// diff lists the lines missing from other with "-" and the unexpected ones with "+".
+func (s schemaSnapshot) diff(other schemaSnapshot) string {
+	expected := make(map[string]int)
+	for _, line := range s {
+		expected[line]++
+	}
+	var extra []string
+	for _, line := range other {
+		if expected[line] > 0 {
+			expected[line]--
+			continue
+		}
+		extra = append(extra, "+ "+line)
+	}
+	var lines []string
+	for _, line := range s {
+		if expected[line] > 0 {
+			expected[line]--
+			lines = append(lines, "- "+line)
+		}
+	}
+	return strings.Join(append(lines, extra...), "\n")
+}
+
+// readMigrationFiles reads the up and down migrations of dir, ordered by version.
+func readMigrationFiles(dir string) ([]*migrationFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[uint]*migrationFile)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		file, ok := byVersion[uint(version)]
+		if !ok {
+			file = &migrationFile{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = file
+		}
+		if match[3] == "up" {
+			file.Up = string(content)
+		} else {
+			file.Down = string(content)
+		}
+	}
+	files := make([]*migrationFile, 0, len(byVersion))
+	for _, file := range byVersion {
+		if file.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up migration", file.Version, file.Name)
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Version < files[j].Version
+	})
+	return files, nil
+}
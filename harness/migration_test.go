@@ -0,0 +1,44 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMigrationFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"000002_contact.up.sql":  "ALTER TABLE supplier ADD contact TEXT;",
+		"000001_init.up.sql":     "CREATE TABLE supplier (id INT);",
+		"000001_init.down.sql":   "DROP TABLE supplier;",
+		"000010_backfill.up.sql": "UPDATE supplier SET contact = '';",
+		"README.md":              "migrations",
+	} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	files, err := readMigrationFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []*migrationFile{
+		{Version: 1, Name: "init", Up: "CREATE TABLE supplier (id INT);", Down: "DROP TABLE supplier;"},
+		{Version: 2, Name: "contact", Up: "ALTER TABLE supplier ADD contact TEXT;"},
+		{Version: 10, Name: "backfill", Up: "UPDATE supplier SET contact = '';"},
+	}, files)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "000011_orphan.down.sql"), []byte("DROP TABLE x;"), 0o644))
+	_, err = readMigrationFiles(dir)
+	assert.EqualError(t, err, "migration 11_orphan has no up migration")
+}
+
+func TestSchemaSnapshot_Diff(t *testing.T) {
+	before := schemaSnapshot{"column supplier id 1 int NO NULL", "table supplier InnoDB utf8mb4_0900_ai_ci"}
+
+	assert.Empty(t, before.diff(schemaSnapshot{"column supplier id 1 int NO NULL", "table supplier InnoDB utf8mb4_0900_ai_ci"}))
+	assert.Equal(t,
+		"- column supplier id 1 int NO NULL\n+ column supplier id 1 bigint NO NULL\n+ index supplier PRIMARY 1 id 0",
+		before.diff(schemaSnapshot{"column supplier id 1 bigint NO NULL", "index supplier PRIMARY 1 id 0", "table supplier InnoDB utf8mb4_0900_ai_ci"}),
+	)
+}
@@ -0,0 +1,71 @@
+package harness
+
+import (
+	"regexp"
+	"strings"
+)
+
+type (
+	// testOutputFilter tells the lines the testing package prints apart from the output of the
+	// services: the t.Log lines and failures are already recorded by Logf and the assertions.
+	testOutputFilter struct {
+		inTestOutput bool
+	}
+)
+
+// testLogLine matches the "    file_test.go:12: message" lines of t.Log and t.Error.
+var testLogLine = regexp.MustCompile(`^\S+\.go:\d+: `)
+
+// keep reports whether line is to be attached to the running test.
+func (f *testOutputFilter) keep(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	switch {
+	case strings.HasPrefix(trimmed, "=== "), strings.HasPrefix(trimmed, "--- "), testLogLine.MatchString(trimmed):
+		f.inTestOutput = true
+		return false
+	case f.inTestOutput && trimmed != line:
+		// The indented lines that follow a t.Log line belong to it, such as the testify traces
+		return false
+	}
+	f.inTestOutput = false
+	return true
+}
+
+// captureTestOutput attaches what the process prints while a test runs, the logs of the services
+// started in process included, to the result of that test.
+func (s *Suite) captureTestOutput() {
+	filter := &testOutputFilter{}
+	output, err := captureOutput(func(line string) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !filter.keep(line) {
+			return
+		}
+		for _, record := range s.records {
+			record.logs.WriteString(line)
+		}
+	})
+	if err != nil {
+		s.T().Logf("could not capture the test output: %v", err)
+		return
+	}
+	s.output = output
+}
+
+// flushTestOutput waits for the output printed so far to be attached.
+func (s *Suite) flushTestOutput() {
+	if s.output != nil {
+		s.output.flush()
+	}
+}
+
+// stopTestOutput gives the process its standard output and error back.
+func (s *Suite) stopTestOutput() {
+	if s.output == nil {
+		return
+	}
+	if err := s.output.stop(); err != nil {
+		s.T().Logf("could not restore the test output: %v", err)
+	}
+	s.output = nil
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package harness
+
+import "errors"
+
+type outputCapture struct{}
+
+func captureOutput(func(line string)) (*outputCapture, error) {
+	return nil, errors.New("capturing the output is not supported on this platform")
+}
+
+func (c *outputCapture) flush() {}
+
+func (c *outputCapture) stop() error {
+	return nil
+}
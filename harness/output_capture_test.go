@@ -0,0 +1,28 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestOutputFilter(t *testing.T) {
+	filter := &testOutputFilter{}
+	lines := []string{
+		"=== RUN   TestSuite/TestGet\n",
+		"service: request received\n",
+		"    get_test.go:42: request: {}\n",
+		"    get_test.go:48: \n",
+		"        \tError Trace:\tget_test.go:48\n",
+		"        \tError:      \tNot equal\n",
+		"{\"level\":\"info\",\"msg\":\"done\"}\n",
+		"    --- FAIL: TestSuite/TestGet (0.01s)\n",
+	}
+	var kept []string
+	for _, line := range lines {
+		if filter.keep(line) {
+			kept = append(kept, line)
+		}
+	}
+	assert.Equal(t, []string{"service: request received\n", "{\"level\":\"info\",\"msg\":\"done\"}\n"}, kept)
+}
@@ -0,0 +1,116 @@
+//go:build unix
+
+package harness
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+type (
+	// outputCapture puts pipes behind the standard output and error of the process, the lines
+	// read from them are still printed and handed to onLine.
+	outputCapture struct {
+		streams []*capturedStream
+	}
+	capturedStream struct {
+		fd      int
+		saved   *os.File
+		writer  *os.File
+		flushed chan struct{}
+		done    chan struct{}
+	}
+)
+
+// flushMarker is written through the pipes to know when what was printed before has been read.
+const flushMarker = "\x00harness-flush\x00\n"
+
+func captureOutput(onLine func(line string)) (*outputCapture, error) {
+	c := &outputCapture{}
+	for _, fd := range []int{unix.Stdout, unix.Stderr} {
+		stream, err := captureStream(fd, onLine)
+		if err != nil {
+			_ = c.stop()
+			return nil, err
+		}
+		c.streams = append(c.streams, stream)
+	}
+	return c, nil
+}
+
+func captureStream(fd int, onLine func(line string)) (*capturedStream, error) {
+	savedFd, err := unix.Dup(fd)
+	if err != nil {
+		return nil, err
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		_ = unix.Close(savedFd)
+		return nil, err
+	}
+	if err = unix.Dup2(int(writer.Fd()), fd); err != nil {
+		_ = unix.Close(savedFd)
+		_ = reader.Close()
+		_ = writer.Close()
+		return nil, err
+	}
+	stream := &capturedStream{
+		fd:      fd,
+		saved:   os.NewFile(uintptr(savedFd), "saved"),
+		writer:  writer,
+		flushed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go stream.copy(reader, onLine)
+	return stream, nil
+}
+
+func (s *capturedStream) copy(reader *os.File, onLine func(line string)) {
+	defer close(s.done)
+	defer reader.Close()
+	lines := bufio.NewReader(reader)
+	for {
+		line, err := lines.ReadString('\n')
+		if strings.HasSuffix(line, flushMarker) {
+			// A line left unfinished before the marker is still printed
+			line = strings.TrimSuffix(line, flushMarker)
+			s.flushed <- struct{}{}
+		}
+		if line != "" {
+			_, _ = s.saved.WriteString(line)
+			onLine(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (c *outputCapture) flush() {
+	for _, stream := range c.streams {
+		if _, err := stream.writer.WriteString(flushMarker); err == nil {
+			<-stream.flushed
+		}
+	}
+}
+
+func (c *outputCapture) stop() error {
+	var firstErr error
+	for _, stream := range c.streams {
+		if err := unix.Dup2(int(stream.saved.Fd()), stream.fd); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		// The reader ends once the last write end of the pipe is closed
+		_ = stream.writer.Close()
+		<-stream.done
+		_ = stream.saved.Close()
+	}
+	c.streams = nil
+	return firstErr
+}
@@ -0,0 +1,35 @@
+package harness
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// ReapSharedMySqlContainers removes the containers shared by the test packages, with their
+// databases. It is meant to run once after all the packages, e.g. as the last CI step.
+func ReapSharedMySqlContainers(ctx context.Context) ([]string, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", sharedContainerLabel)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var reaped []string
+	for _, c := range containers {
+		err = cli.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
+		if err != nil {
+			return reaped, err
+		}
+		reaped = append(reaped, c.Names...)
+	}
+	return reaped, nil
+}
@@ -0,0 +1,213 @@
+package harness
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+type (
+	// JUnitTestSuites is the root of the JUnit XML report, as read by most CI dashboards.
+	JUnitTestSuites struct {
+		XMLName  xml.Name          `xml:"testsuites"`
+		Tests    int               `xml:"tests,attr"`
+		Failures int               `xml:"failures,attr"`
+		Time     string            `xml:"time,attr"`
+		Suites   []*JUnitTestSuite `xml:"testsuite"`
+	}
+	JUnitTestSuite struct {
+		Name      string           `xml:"name,attr"`
+		Package   string           `xml:"package,attr,omitempty"`
+		Tests     int              `xml:"tests,attr"`
+		Failures  int              `xml:"failures,attr"`
+		Time      string           `xml:"time,attr"`
+		Timestamp string           `xml:"timestamp,attr"`
+		Cases     []*JUnitTestCase `xml:"testcase"`
+	}
+	JUnitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *JUnitFailure `xml:"failure,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+	JUnitFailure struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+	// TestReport is the JSON summary of a suite run.
+	TestReport struct {
+		Suite      string            `json:"suite"`
+		Package    string            `json:"package,omitempty"`
+		Timestamp  string            `json:"timestamp"`
+		DurationMs int64             `json:"durationMs"`
+		Tests      int               `json:"tests"`
+		Failures   int               `json:"failures"`
+		Cases      []*TestReportCase `json:"cases"`
+	}
+	TestReportCase struct {
+		Name       string   `json:"name"`
+		Status     string   `json:"status"`
+		DurationMs int64    `json:"durationMs"`
+		Issues     []string `json:"issues,omitempty"`
+		Failure    string   `json:"failure,omitempty"`
+		Logs       string   `json:"logs,omitempty"`
+	}
+)
+
+const (
+	// testReportDirEnv points at the directory the JUnit XML and JSON reports are written to
+	testReportDirEnv    = "TEST_REPORT_DIR"
+	testReportTimestamp = "2006-01-02T15:04:05"
+)
+
+// writeReports writes <package>_<suite>.xml and <package>_<suite>.json under dir from the
+// collected tests, the slashes of the package import path are replaced by underscores.
+func (s *Suite) writeReports(dir string) error {
+	report := s.report()
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	name := reportName(report.Package, report.Suite)
+	data, err := xml.MarshalIndent(report.junit(), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	if err = os.WriteFile(filepath.Join(dir, name+".xml"), append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	data, err = json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name+".json"), append(data, '\n'), 0o644)
+}
+
+// report collapses the tests the same way as they are pushed and merges the issues they are linked to.
+func (s *Suite) report() *TestReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	report := &TestReport{
+		Suite:     s.suiteName,
+		Package:   s.pkgPath,
+		Timestamp: s.start.UTC().Format(testReportTimestamp),
+		Cases:     make([]*TestReportCase, 0),
+	}
+	cases := make(map[string]*TestReportCase)
+	for _, issueKey := range s.keys {
+		for _, t := range s.getRunTests(s.tests[issueKey]) {
+			c, exist := cases[t.name]
+			if !exist {
+				c = &TestReportCase{
+					Name:       strings.TrimPrefix(t.name, s.suiteName+"/"),
+					Status:     t.status,
+					DurationMs: t.duration.Milliseconds(),
+					Failure:    t.failure,
+					Logs:       t.logs,
+				}
+				cases[t.name] = c
+				report.Cases = append(report.Cases, c)
+				report.Tests++
+				if t.status == "Fail" {
+					report.Failures++
+				}
+				report.DurationMs += c.DurationMs
+			}
+			if issueKey != "" {
+				c.Issues = append(c.Issues, issueKey)
+			}
+		}
+	}
+	return report
+}
+
+func (r *TestReport) junit() *JUnitTestSuites {
+	suite := &JUnitTestSuite{
+		Name:      r.Suite,
+		Package:   r.Package,
+		Tests:     r.Tests,
+		Failures:  r.Failures,
+		Time:      junitSeconds(r.DurationMs),
+		Timestamp: r.Timestamp,
+	}
+	for _, c := range r.Cases {
+		testCase := &JUnitTestCase{
+			Name:      c.Name,
+			Classname: r.classname(),
+			Time:      junitSeconds(c.DurationMs),
+			SystemOut: c.Logs,
+		}
+		if c.Status == "Fail" {
+			testCase.Failure = &JUnitFailure{Message: firstLine(c.Failure), Text: c.Failure}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	return &JUnitTestSuites{
+		Tests:    r.Tests,
+		Failures: r.Failures,
+		Time:     suite.Time,
+		Suites:   []*JUnitTestSuite{suite},
+	}
+}
+
+// classname qualifies the suite with its package, suites of different packages may have the same name.
+func (r *TestReport) classname() string {
+	if r.Package == "" {
+		return r.Suite
+	}
+	return r.Package + "." + r.Suite
+}
+
+// reportName is the name of the report files of suite, unique across the packages of the module.
+func reportName(pkgPath string, suite string) string {
+	name := suite
+	if pkgPath != "" {
+		name = pkgPath + "/" + suite
+	}
+	return strings.ReplaceAll(name, "/", "_")
+}
+
+// testPackage returns the import path of the package of the running top-level test, read from the
+// function started by testing.tRunner, or "" when it is not on the stack of the caller.
+func testPackage() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	test := ""
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "testing.tRunner" {
+			return funcPackage(test)
+		}
+		if !more {
+			return ""
+		}
+		test = frame.Function
+	}
+}
+
+// funcPackage returns the import path of a function name such as "example.com/pkg.TestRun.func1".
+func funcPackage(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return function[:slash+1+dot]
+}
+
+func junitSeconds(ms int64) string {
+	return fmt.Sprintf("%.3f", (time.Duration(ms) * time.Millisecond).Seconds())
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
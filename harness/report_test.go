@@ -0,0 +1,100 @@
+package harness
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newReportSuite() *Suite {
+	s := &Suite{
+		suiteName: "TestExportForecast",
+		pkgPath:   "go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests/internal/consumer",
+		start:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		keys:      []string{"OMNI-1", "OMNI-2"},
+		tests:     make(map[string][]*testInfo),
+	}
+	s.addTest("TestExportForecast/TestExport", false, &testInfo{status: "Fail"})
+	s.addTest("TestExportForecast/TestExport/happy case", true, &testInfo{status: "Pass", duration: 1500 * time.Millisecond})
+	s.addTest("TestExportForecast/TestExport/error case", true, &testInfo{
+		status:   "Fail",
+		duration: 20 * time.Millisecond,
+		failure:  "Error: Not equal\nexpected: 1",
+		logs:     "request: {}\n",
+	})
+	s.addTest("TestExportForecast/TestValidate", false, &testInfo{status: "Pass", duration: time.Millisecond})
+	return s
+}
+
+func TestSuite_Report(t *testing.T) {
+	report := newReportSuite().report()
+
+	assert.Equal(t, "TestExportForecast", report.Suite)
+	assert.Equal(t, "go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests/internal/consumer", report.Package)
+	assert.Equal(t, "2024-01-02T03:04:05", report.Timestamp)
+	assert.Equal(t, 3, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	assert.Equal(t, int64(1521), report.DurationMs)
+	assert.Equal(t, []*TestReportCase{
+		{Name: "TestExport/happy case", Status: "Pass", DurationMs: 1500, Issues: []string{"OMNI-1", "OMNI-2"}},
+		{Name: "TestExport/error case", Status: "Fail", DurationMs: 20, Issues: []string{"OMNI-1", "OMNI-2"}, Failure: "Error: Not equal\nexpected: 1", Logs: "request: {}\n"},
+		{Name: "TestValidate", Status: "Pass", DurationMs: 1, Issues: []string{"OMNI-1", "OMNI-2"}},
+	}, report.Cases)
+}
+
+func TestSuite_WriteReports(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+
+	err := newReportSuite().writeReports(dir)
+	assert.Nil(t, err)
+
+	name := "go.tekoapis.com_tekone_app_supplychain_demand_planning_service_tests_internal_consumer_TestExportForecast"
+	data, err := os.ReadFile(filepath.Join(dir, name+".xml"))
+	assert.Nil(t, err)
+	junit := &JUnitTestSuites{}
+	assert.Nil(t, xml.Unmarshal(data, junit))
+	assert.Equal(t, 3, junit.Tests)
+	assert.Equal(t, 1, junit.Failures)
+	assert.Equal(t, "1.521", junit.Time)
+	if assert.Len(t, junit.Suites, 1) {
+		assert.Equal(t, "go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests/internal/consumer", junit.Suites[0].Package)
+	}
+	cases := junit.Suites[0].Cases
+	assert.Len(t, cases, 3)
+	assert.Equal(t, "TestExport/error case", cases[1].Name)
+	assert.Equal(t, "go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests/internal/consumer.TestExportForecast", cases[1].Classname)
+	assert.Equal(t, "0.020", cases[1].Time)
+	assert.Equal(t, &JUnitFailure{Message: "Error: Not equal", Text: "Error: Not equal\nexpected: 1"}, cases[1].Failure)
+	assert.Equal(t, "request: {}\n", cases[1].SystemOut)
+	assert.Nil(t, cases[0].Failure)
+
+	data, err = os.ReadFile(filepath.Join(dir, name+".json"))
+	assert.Nil(t, err)
+	report := &TestReport{}
+	assert.Nil(t, json.Unmarshal(data, report))
+	assert.Equal(t, 3, report.Tests)
+	assert.Equal(t, "Fail", report.Cases[1].Status)
+}
+
+func TestReportName(t *testing.T) {
+	assert.Equal(t, "TestExportForecast", reportName("", "TestExportForecast"))
+	// Suites with the same name in two packages do not overwrite each other
+	assert.NotEqual(t,
+		reportName("go.tekoapis.com/tekone/app/supplychain/demand_planning_service/tests/api", "TestCaseSuite"),
+		reportName("go.tekoapis.com/tekone/app/supplychain/supplier_service/tests/api/contact_management", "TestCaseSuite"))
+}
+
+func TestTestPackage(t *testing.T) {
+	assert.Equal(t, "go.tekoapis.com/tekone/app/supplychain/tests/harness", testPackage())
+	t.Run("subtest", func(t *testing.T) {
+		assert.Equal(t, "go.tekoapis.com/tekone/app/supplychain/tests/harness", testPackage())
+	})
+	assert.Equal(t, "example.com/pkg", funcPackage("example.com/pkg.TestRun.func1"))
+	assert.Equal(t, "example.com/pkg", funcPackage("example.com/pkg.(*Suite).Run"))
+	assert.Equal(t, "", funcPackage("main"))
+}
@@ -0,0 +1,273 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type (
+	// DatabaseOptions relax the schema of the database of a test package, like the options of
+	// the same names of integrationtest, for the suites which seed rows without their parents or
+	// with duplicate keys. The copies of the database keep the relaxed schema.
+	DatabaseOptions struct {
+		// IsDisableCheckForeignKey drops the foreign keys
+		IsDisableCheckForeignKey bool
+		// IsDisableUniqueKey drops the unique indexes, the primary keys are kept
+		IsDisableUniqueKey bool
+	}
+	// tableKey is a foreign key or an index of a table
+	tableKey struct {
+		TableName string
+		KeyName   string
+	}
+)
+
+// maxDatabaseName is the longest database name accepted by MySQL
+const maxDatabaseName = 64
+
+var (
+	cloneSeq            int64
+	autoIncrementOption = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+)
+
+// CloneDatabase copies the tables and rows of the database of template, usually freshly
+// migrated, into a new database and connects to it. The copy is dropped when t ends, so a
+// suite, or a single test, owns its data and can run in parallel with the others against
+// the same MySQL server instead of truncating the shared tables.
+func CloneDatabase(t testing.TB, template *gorm.DB) *gorm.DB {
+	t.Helper()
+	db, drop, err := NewDatabaseCopy(template)
+	if err != nil {
+		t.Fatalf("could not clone database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := drop(); err != nil {
+			t.Errorf("could not drop database: %v", err)
+		}
+	})
+	return db
+}
+
+// NewDatabaseCopy is CloneDatabase for callers without a test, such as TestMain. drop
+// closes the connection to the copy and drops it.
+func NewDatabaseCopy(template *gorm.DB) (*gorm.DB, func() error, error) {
+	db, name, err := cloneDatabase(template)
+	drop := func() error {
+		if db != nil {
+			if sqlDB, err := db.DB(); err == nil {
+				_ = sqlDB.Close()
+			}
+		}
+		return template.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name)).Error
+	}
+	if err != nil {
+		if name != "" {
+			_ = drop()
+		}
+		return nil, nil, err
+	}
+	return db, drop, nil
+}
+
+// IsolatedDB clones template for t, a test or the function running the suite, and makes
+// the copy the database of the suite, see CloneDatabase.
+func (s *Suite) IsolatedDB(t testing.TB, template *gorm.DB) *gorm.DB {
+	t.Helper()
+	db := CloneDatabase(t, template)
+	s.DbName = db.Migrator().CurrentDatabase()
+	return db
+}
+
+func cloneDatabase(template *gorm.DB) (*gorm.DB, string, error) {
+	cfg, err := dsnConfig(template)
+	if err != nil {
+		return nil, "", err
+	}
+	templateName := template.Migrator().CurrentDatabase()
+	if err = checkCloneable(template, templateName); err != nil {
+		return nil, "", err
+	}
+	name := cloneName(templateName, os.Getpid(), atomic.AddInt64(&cloneSeq, 1))
+	if err = template.Exec(fmt.Sprintf("CREATE DATABASE `%s`", name)).Error; err != nil {
+		return nil, "", err
+	}
+	cfg.DBName = name
+	db, err := gorm.Open(mysql.Open(cfg.FormatDSN()), &gorm.Config{Logger: template.Logger})
+	if err != nil {
+		return nil, name, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return db, name, err
+	}
+	// Foreign key checks are disabled for the session, the tables are copied in name order
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.SetMaxOpenConns(0)
+	if err = db.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+		return db, name, err
+	}
+	var tables []string
+	err = db.Raw(
+		"SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+		templateName,
+	).Scan(&tables).Error
+	if err != nil {
+		return db, name, err
+	}
+	for _, table := range tables {
+		if err = copyTable(db, templateName, table); err != nil {
+			return db, name, fmt.Errorf("copy table %s: %w", table, err)
+		}
+	}
+	return db, name, db.Exec("SET FOREIGN_KEY_CHECKS = 1").Error
+}
+
+// relaxSchema drops the foreign keys and the unique indexes of db as asked by opts. The foreign
+// keys go first, as MySQL refuses to drop an index used by one.
+func relaxSchema(db *gorm.DB, opts DatabaseOptions) error {
+	name := db.Migrator().CurrentDatabase()
+	var foreignKeys, uniqueKeys []tableKey
+	if opts.IsDisableCheckForeignKey {
+		err := db.Raw(
+			"SELECT TABLE_NAME AS table_name, CONSTRAINT_NAME AS key_name FROM information_schema.TABLE_CONSTRAINTS WHERE CONSTRAINT_SCHEMA = ? AND CONSTRAINT_TYPE = 'FOREIGN KEY' ORDER BY TABLE_NAME, CONSTRAINT_NAME",
+			name,
+		).Scan(&foreignKeys).Error
+		if err != nil {
+			return err
+		}
+	}
+	if opts.IsDisableUniqueKey {
+		err := db.Raw(
+			"SELECT DISTINCT TABLE_NAME AS table_name, INDEX_NAME AS key_name FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = ? AND NON_UNIQUE = 0 AND INDEX_NAME <> 'PRIMARY' ORDER BY TABLE_NAME, INDEX_NAME",
+			name,
+		).Scan(&uniqueKeys).Error
+		if err != nil {
+			return err
+		}
+	}
+	for _, statement := range relaxStatements(foreignKeys, uniqueKeys) {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func relaxStatements(foreignKeys []tableKey, uniqueKeys []tableKey) []string {
+	statements := make([]string, 0, len(foreignKeys)+len(uniqueKeys))
+	for _, key := range foreignKeys {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE `%s` DROP FOREIGN KEY `%s`", key.TableName, key.KeyName))
+	}
+	for _, key := range uniqueKeys {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`", key.TableName, key.KeyName))
+	}
+	return statements
+}
+
+// checkCloneable rejects the databases with views or triggers: their definitions name the
+// template database, so they can't be copied as they are.
+func checkCloneable(db *gorm.DB, templateName string) error {
+	var views, triggers []string
+	err := db.Raw(
+		"SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'VIEW' ORDER BY TABLE_NAME",
+		templateName,
+	).Scan(&views).Error
+	if err != nil {
+		return err
+	}
+	err = db.Raw(
+		"SELECT TRIGGER_NAME FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = ? ORDER BY TRIGGER_NAME",
+		templateName,
+	).Scan(&triggers).Error
+	if err != nil {
+		return err
+	}
+	return notCloneableError(templateName, views, triggers)
+}
+
+func notCloneableError(templateName string, views []string, triggers []string) error {
+	var objects []string
+	if len(views) > 0 {
+		objects = append(objects, "views "+strings.Join(views, ", "))
+	}
+	if len(triggers) > 0 {
+		objects = append(objects, "triggers "+strings.Join(triggers, ", "))
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+	return fmt.Errorf("database %s can't be cloned, it has %s", templateName, strings.Join(objects, " and "))
+}
+
+// copyTable creates table from its definition, which keeps the foreign keys unlike
+// CREATE TABLE ... LIKE, then copies its rows. The generated columns are left to MySQL.
+func copyTable(db *gorm.DB, templateName string, table string) error {
+	var name, createTable string
+	row := db.Raw(fmt.Sprintf("SHOW CREATE TABLE `%s`.`%s`", templateName, table)).Row()
+	if err := row.Scan(&name, &createTable); err != nil {
+		return err
+	}
+	if err := db.Exec(withoutAutoIncrement(createTable)).Error; err != nil {
+		return err
+	}
+	var columns []string
+	err := db.Raw(
+		"SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND GENERATION_EXPRESSION = '' ORDER BY ORDINAL_POSITION",
+		templateName, table,
+	).Scan(&columns).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec(copyRowsStatement(templateName, table, columns)).Error
+}
+
+// withoutAutoIncrement drops the counter of the template from its table definition, so the
+// ids of the copy follow its rows, like after a TRUNCATE.
+func withoutAutoIncrement(createTable string) string {
+	return autoIncrementOption.ReplaceAllString(createTable, "")
+}
+
+func copyRowsStatement(templateName string, table string, columns []string) string {
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, "`"+column+"`")
+	}
+	list := strings.Join(quoted, ", ")
+	return fmt.Sprintf("INSERT INTO `%s` (%s) SELECT %s FROM `%s`.`%s`", table, list, list, templateName, table)
+}
+
+// dsnConfig returns the connection settings of db, which must have been opened with a DSN.
+func dsnConfig(db *gorm.DB) (*mysqlDriver.Config, error) {
+	var dsn string
+	switch dialector := db.Dialector.(type) {
+	case *mysql.Dialector:
+		dsn = dialector.DSN
+	case mysql.Dialector:
+		dsn = dialector.DSN
+	default:
+		return nil, fmt.Errorf("unsupported dialector %s", db.Dialector.Name())
+	}
+	if dsn == "" {
+		return nil, errors.New("the template database was not opened with a dsn")
+	}
+	return mysqlDriver.ParseDSN(dsn)
+}
+
+// cloneName builds a database name unique to the process, trimming the template name
+// to stay within the MySQL limit.
+func cloneName(templateName string, pid int, seq int64) string {
+	suffix := fmt.Sprintf("_%d_%d", pid, seq)
+	if len(templateName)+len(suffix) > maxDatabaseName {
+		templateName = templateName[:maxDatabaseName-len(suffix)]
+	}
+	return templateName + suffix
+}
@@ -0,0 +1,63 @@
+package harness
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestCloneName(t *testing.T) {
+	assert.Equal(t, "supplier_42_7", cloneName("supplier", 42, 7))
+
+	name := cloneName(strings.Repeat("a", 70), 12345, 1)
+	assert.Len(t, name, maxDatabaseName)
+	assert.True(t, strings.HasSuffix(name, "a_12345_1"))
+}
+
+func TestDsnConfig(t *testing.T) {
+	db := &gorm.DB{Config: &gorm.Config{Dialector: mysql.Open("root:secret@tcp(127.0.0.1:3306)/supplier?parseTime=true")}}
+
+	cfg, err := dsnConfig(db)
+	assert.Nil(t, err)
+	assert.Equal(t, "supplier", cfg.DBName)
+	cfg.DBName = "supplier_1_1"
+	assert.Equal(t, "root:secret@tcp(127.0.0.1:3306)/supplier_1_1?parseTime=true", cfg.FormatDSN())
+
+	_, err = dsnConfig(&gorm.DB{Config: &gorm.Config{Dialector: mysql.New(mysql.Config{})}})
+	assert.NotNil(t, err)
+}
+
+func TestNotCloneableError(t *testing.T) {
+	assert.Nil(t, notCloneableError("supplier", nil, nil))
+	assert.EqualError(t, notCloneableError("supplier", []string{"v_supplier"}, nil),
+		"database supplier can't be cloned, it has views v_supplier")
+	assert.EqualError(t, notCloneableError("supplier", []string{"v_a", "v_b"}, []string{"tr_supplier"}),
+		"database supplier can't be cloned, it has views v_a, v_b and triggers tr_supplier")
+}
+
+func TestWithoutAutoIncrement(t *testing.T) {
+	createTable := "CREATE TABLE `supplier` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4"
+	assert.Equal(t,
+		"CREATE TABLE `supplier` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		withoutAutoIncrement(createTable))
+}
+
+func TestCopyRowsStatement(t *testing.T) {
+	assert.Equal(t,
+		"INSERT INTO `supplier` (`id`, `name`) SELECT `id`, `name` FROM `supplier_tmpl`.`supplier`",
+		copyRowsStatement("supplier_tmpl", "supplier", []string{"id", "name"}))
+}
+
+func TestRelaxStatements(t *testing.T) {
+	assert.Empty(t, relaxStatements(nil, nil))
+	assert.Equal(t, []string{
+		"ALTER TABLE `supplier_contact` DROP FOREIGN KEY `fk_supplier_contact_supplier`",
+		"ALTER TABLE `supplier` DROP INDEX `uk_supplier_code`",
+	}, relaxStatements(
+		[]tableKey{{TableName: "supplier_contact", KeyName: "fk_supplier_contact_supplier"}},
+		[]tableKey{{TableName: "supplier", KeyName: "uk_supplier_code"}},
+	))
+}
@@ -0,0 +1,51 @@
+package harness
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	grpcMiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpcValidator "github.com/grpc-ecosystem/go-grpc-middleware/validator"
+	grpcPrometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// InitServer serves what register adds to the server on an in-memory listener and dials it.
+// interceptors are chained after the prometheus and validator ones shared by every service.
+// Each call owns its listener, so suites can run in parallel, and t stops the server when done.
+func (s *Suite) InitServer(t *testing.T, register func(*grpc.Server), interceptors ...grpc.UnaryServerInterceptor) (*grpc.ClientConn, *grpc.Server) {
+	lis := bufconn.Listen(bufSize)
+	chain := append([]grpc.UnaryServerInterceptor{
+		grpcPrometheus.UnaryServerInterceptor,
+		grpcValidator.UnaryServerInterceptor(),
+	}, interceptors...)
+	server := grpc.NewServer(
+		grpcMiddleware.WithUnaryServerChain(chain...),
+	)
+	register(server)
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			panic("Server exited with error")
+		}
+	}()
+	bufDialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+	s.Context = context.Background()
+	conn, err := grpc.DialContext(s.Context, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		server.Stop()
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	t.Cleanup(func() {
+		// The suites may already have closed them, so errors are expected here
+		_ = conn.Close()
+		server.GracefulStop()
+		_ = lis.Close()
+	})
+	return conn, server
+}
@@ -0,0 +1,37 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestSuite_InitServer_Parallel(t *testing.T) {
+	statuses := []grpc_health_v1.HealthCheckResponse_ServingStatus{
+		grpc_health_v1.HealthCheckResponse_SERVING,
+		grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+	}
+	for i, status := range statuses {
+		status := status
+		t.Run(fmt.Sprintf("suite%d", i), func(t *testing.T) {
+			t.Parallel()
+			s := &Suite{}
+			healthServer := health.NewServer()
+			healthServer.SetServingStatus("", status)
+			conn, _ := s.InitServer(t, func(server *grpc.Server) {
+				grpc_health_v1.RegisterHealthServer(server, healthServer)
+			})
+			client := grpc_health_v1.NewHealthClient(conn)
+			for j := 0; j < 10; j++ {
+				res, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+				assert.Nil(t, err)
+				assert.Equal(t, status, res.GetStatus())
+			}
+		})
+	}
+}
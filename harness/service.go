@@ -0,0 +1,429 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const JiraBaseTestFolder = "/HN7/API/SC"
+const JiraProjectKey = "ERP2020"
+
+// unitTestFile is looked up from the test directory and its parents
+const unitTestFile = "unittest.json"
+
+const (
+	jiraUrlEnv        = "JIRA_URL"
+	jiraProjectKeyEnv = "JIRA_PROJECT_KEY"
+	jiraUserNameEnv   = "JIRA_USERNAME"
+	jiraPasswordEnv   = "JIRA_PASSWORD"
+	jiraTokenEnv      = "JIRA_TOKEN"
+)
+
+const (
+	BackendJira     = "jira"
+	BackendXray     = "xray"
+	BackendTestRail = "testrail"
+	BackendFile     = "file"
+)
+
+type (
+	TestScript struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	TestCase struct {
+		Name       string     `json:"name"`
+		ProjectKey string     `json:"projectKey"`
+		IssueLinks []string   `json:"issueLinks"`
+		Objective  string     `json:"objective"`
+		Folder     string     `json:"folder"`
+		TestScript TestScript `json:"testScript"`
+		Status     string     `json:"status"`
+		// Result of the run given to PushTests, they are sent with the cycle item
+		ExecutionTime int64  `json:"-"`
+		Comment       string `json:"-"`
+		Logs          string `json:"-"`
+	}
+	TestCycleItem struct {
+		TestCaseKey   string        `json:"testCaseKey"`
+		Status        string        `json:"status"`
+		ExecutionTime int64         `json:"executionTime,omitempty"`
+		Comment       string        `json:"comment,omitempty"`
+		Attachments   []*Attachment `json:"-"`
+	}
+	Attachment struct {
+		Name        string `json:"name"`
+		ContentType string `json:"contentType"`
+		Data        []byte `json:"-"`
+	}
+	Folder struct {
+		ProjectKey string `json:"projectKey"`
+		Name       string `json:"name"`
+		Type       string `json:"type"`
+	}
+	Issue struct {
+		Key    string
+		Status string
+		Epic   string
+	}
+	TestResult struct {
+		Key      string `json:"key"`
+		Name     string `json:"name"`
+		Status   string `json:"status"`
+		CreateBy string `json:"createdBy"`
+	}
+	TestCycle struct {
+		Name             string           `json:"name"`
+		ProjectKey       string           `json:"projectKey"`
+		IssueKey         string           `json:"issueKey"`
+		PlannedStartDate string           `json:"plannedStartDate"`
+		PlannedEndDate   string           `json:"plannedEndDate"`
+		Folder           string           `json:"folder"`
+		Items            []*TestCycleItem `json:"items"`
+	}
+
+	IssueManagerConfig struct {
+		Backend    string `json:"backend"`
+		Url        string `json:"url"`
+		ProjectKey string `json:"projectKey"`
+		UserName   string `json:"username"`
+		Password   string `json:"password"`
+		Token      string `json:"token"`
+		RetryCount int    `json:"retryCount"`
+		// RateLimit is the maximum number of requests per second
+		RateLimit int `json:"rateLimit"`
+		// TestRail only
+		ProjectId int `json:"projectId"`
+		SuiteId   int `json:"suiteId"`
+		// File store only, relative paths are resolved against the config file
+		Dir string `json:"dir"`
+	}
+
+	TestData struct {
+		mu          sync.RWMutex
+		tests       map[string][]*TestCase
+		cycleTests  map[string]string
+		issueManSrv IssueManagerService
+		workers     int
+	}
+)
+
+// defaultPushWorkers is the number of tests created or updated concurrently by PushTests
+const defaultPushWorkers = 8
+
+var instance *TestData
+
+func GetTest() *TestData {
+	if instance == nil {
+		instance = NewTestData(LoadDefaultConfig())
+	}
+	return instance
+}
+
+func NewTestData(issueManSrv IssueManagerService) *TestData {
+	return &TestData{
+		tests:       make(map[string][]*TestCase),
+		cycleTests:  make(map[string]string),
+		issueManSrv: issueManSrv,
+		workers:     defaultPushWorkers,
+	}
+}
+
+// removeOldTests deletes the cycle of the previous suite of issueKey and keeps its tests,
+// so every suite of the issue ends up in one cycle.
+func (t *TestData) removeOldTests(issueKey string, tests []*TestCase, pushErr *PushError) {
+	newTests, exist := t.tests[issueKey]
+	if !exist {
+		newTests = []*TestCase{}
+	} else if oldTestCycle := t.cycleTests[issueKey]; oldTestCycle != "" {
+		pushErr.add(t.issueManSrv.DeleteTestCycle(oldTestCycle))
+		delete(t.cycleTests, issueKey)
+	}
+	newTests = append(newTests, tests...)
+	t.tests[issueKey] = newTests
+}
+
+// existingTests maps the name of the tests already linked to issueKey to their key, so they
+// are updated instead of recreated. With removeOld, linked tests which are not pushed anymore
+// and duplicated names are deleted.
+func (t *TestData) existingTests(issueKey string, tests []*TestCase, removeOld bool, pushErr *PushError) map[string]string {
+	existing := make(map[string]string)
+	oldTests, err := t.issueManSrv.ListTests(issueKey)
+	if err != nil {
+		pushErr.add(err)
+		return existing
+	}
+	pushed := make(map[string]bool, len(tests))
+	for _, test := range tests {
+		pushed[test.Name] = true
+	}
+	var removed int
+	for _, oldItem := range oldTests {
+		if _, ok := existing[oldItem.Name]; !ok && pushed[oldItem.Name] {
+			existing[oldItem.Name] = oldItem.Key
+			continue
+		}
+		if removeOld {
+			pushErr.add(t.issueManSrv.DeleteTest(oldItem.Key))
+			removed++
+		}
+	}
+	testLog.Info("match old tests", "issueKey", issueKey, "reused", len(existing), "removed", removed)
+	return existing
+}
+
+// upsertTests creates or updates testCases with at most t.workers concurrent requests.
+// The returned keys keep the order of testCases, a failed test has an empty key.
+func (t *TestData) upsertTests(testCases []*TestCase, existing map[string]string, pushErr *PushError) []string {
+	keys := make([]string, len(testCases))
+	errs := make([]error, len(testCases))
+	jobs := make(chan int)
+	workers := t.workers
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				keys[i], errs[i] = t.upsertTest(testCases[i], existing[testCases[i].Name])
+			}
+		}()
+	}
+	for i := range testCases {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			pushErr.add(fmt.Errorf("push test %q: %w", testCases[i].Name, err))
+		}
+	}
+	return keys
+}
+
+func (t *TestData) upsertTest(testCase *TestCase, testKey string) (string, error) {
+	if testKey == "" {
+		return t.issueManSrv.CreateTest(testCase)
+	}
+	return testKey, t.issueManSrv.UpdateTest(testKey, testCase)
+}
+
+// PushTests creates the tests of issueKey and a cycle with their results. Every failing
+// request is collected into the returned *PushError instead of aborting the push.
+func (t *TestData) PushTests(issueKey string, apiName string, testFolder string, tests []*TestCase) error {
+	if t.issueManSrv == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pushErr := &PushError{IssueKey: issueKey}
+	issue, err := t.issueManSrv.GetIssue(issueKey)
+	if err != nil {
+		pushErr.add(err)
+		return pushErr
+	}
+	if strings.ToLower(issue.Status) == "closed" || strings.ToLower(issue.Status) == "done" {
+		return nil
+	}
+	removeOld := os.Getenv("remove_old_test") != ""
+	if removeOld {
+		t.removeOldTests(issueKey, tests, pushErr)
+	} else {
+		t.tests[issueKey] = tests
+	}
+	createdFolders := map[string]bool{}
+	status := "Draft"
+	//if strings.ToUpper(issue.Status) == "OPEN" ||
+	//	strings.ToUpper(issue.Status) == "READY FOR DEV" ||
+	//	strings.ToUpper(issue.Status) == "API FUNCTIONAL TEST CASE REVIEW" ||
+	//	strings.ToUpper(issue.Status) == "WRITE API FUNCTIONAL TEST" ||
+	//	strings.ToUpper(issue.Status) == "TO DO" {
+	//	status = "Draft"
+	//}
+	folder := fmt.Sprintf("%s/%s", JiraBaseTestFolder, testFolder)
+	if _, ok := createdFolders[folder]; !ok {
+		pushErr.add(t.issueManSrv.CreateFolder(folder))
+		createdFolders[folder] = true
+	}
+	testCases := make([]*TestCase, 0, len(t.tests[issueKey]))
+	for _, item := range t.tests[issueKey] {
+		testCases = append(testCases, &TestCase{
+			Name:       item.Name,
+			IssueLinks: []string{issueKey},
+			Folder:     folder,
+			ProjectKey: JiraProjectKey,
+			Objective:  "",
+			TestScript: TestScript{
+				Type: "PLAIN_TEXT",
+				Text: "",
+			},
+			Status: status,
+		})
+	}
+	existing := t.existingTests(issueKey, testCases, removeOld, pushErr)
+	testKeys := t.upsertTests(testCases, existing, pushErr)
+	cycleItems := make([]*TestCycleItem, 0, len(testKeys))
+	for i, testKey := range testKeys {
+		if testKey == "" {
+			continue
+		}
+		item := t.tests[issueKey][i]
+		cycleItem := &TestCycleItem{
+			TestCaseKey:   testKey,
+			Status:        item.Status,
+			ExecutionTime: item.ExecutionTime,
+			Comment:       item.Comment,
+		}
+		if item.Logs != "" {
+			cycleItem.Attachments = append(cycleItem.Attachments, &Attachment{
+				Name:        fmt.Sprintf("%s.log", testKey),
+				ContentType: "text/plain",
+				Data:        []byte(item.Logs),
+			})
+		}
+		cycleItems = append(cycleItems, cycleItem)
+	}
+	now := time.Now().Format("2006-01-02T15:04:05")
+	cycle := &TestCycle{
+		Name:             fmt.Sprintf("[%s][%s]%s", issue.Epic, issue.Key, apiName),
+		IssueKey:         issueKey,
+		Folder:           folder,
+		ProjectKey:       JiraProjectKey,
+		PlannedStartDate: now,
+		PlannedEndDate:   now,
+		Items:            cycleItems,
+	}
+	// A cycle key may come back with an error when only its attachments failed
+	cycleKey, err := t.issueManSrv.CreateTestCycle(cycle)
+	if cycleKey != "" {
+		t.cycleTests[issueKey] = cycleKey
+	}
+	if err != nil {
+		pushErr.add(fmt.Errorf("create test cycle: %w", err))
+	}
+	return pushErr.errOrNil()
+}
+
+// LoadDefaultConfig reads unittest.json then lets the environment override it, so
+// credentials can be given by CI without being written to the config file.
+func LoadDefaultConfig() IssueManagerService {
+	cfg := &IssueManagerConfig{}
+	path := unitTestFile
+	var config *os.File
+	var err error
+	for i := 0; i < 5; i++ {
+		config, err = os.Open(path)
+		if err != nil {
+			path = "../" + path
+			continue
+		}
+		break
+	}
+	if err == nil && config != nil {
+		defer config.Close()
+		decoder := json.NewDecoder(config)
+		if err = decoder.Decode(cfg); err != nil {
+			testLog.Error(err, "cannot parse unittest config file, testcases won't be pushed", "path", path)
+			return nil
+		}
+		if cfg.Dir != "" && !filepath.IsAbs(cfg.Dir) {
+			cfg.Dir = filepath.Join(filepath.Dir(path), cfg.Dir)
+		}
+	}
+	cfg.loadEnv()
+	if cfg.Url == "" && cfg.Dir == "" {
+		testLog.Info("cannot find unittest config file, testcases won't be pushed")
+		return nil
+	}
+	srv, err := NewIssueManagerService(cfg)
+	if err != nil {
+		testLog.Error(err, "cannot create issue manager, testcases won't be pushed", "backend", cfg.Backend)
+		return nil
+	}
+	return srv
+}
+
+func (cfg *IssueManagerConfig) loadEnv() {
+	envs := map[string]*string{
+		jiraUrlEnv:        &cfg.Url,
+		jiraProjectKeyEnv: &cfg.ProjectKey,
+		jiraUserNameEnv:   &cfg.UserName,
+		jiraPasswordEnv:   &cfg.Password,
+		jiraTokenEnv:      &cfg.Token,
+	}
+	for env, field := range envs {
+		if value := os.Getenv(env); value != "" {
+			*field = value
+		}
+	}
+}
+
+// NewIssueManagerService builds the IssueManagerService selected by cfg.Backend.
+// An empty backend keeps the historical Zephyr/ATM behaviour.
+func NewIssueManagerService(cfg *IssueManagerConfig) (IssueManagerService, error) {
+	clientCfg := HttpClientConfig{
+		RetryCount: cfg.RetryCount,
+		RateLimit:  cfg.RateLimit,
+	}
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendJira:
+		return &Jira{
+			HttpClientConfig: clientCfg,
+			ProjectKey:       cfg.ProjectKey,
+			UserName:         cfg.UserName,
+			Password:         cfg.Password,
+			Token:            cfg.Token,
+			Url:              cfg.Url,
+		}, nil
+	case BackendXray:
+		return &Xray{
+			Jira: Jira{
+				HttpClientConfig: clientCfg,
+				ProjectKey:       cfg.ProjectKey,
+				UserName:         cfg.UserName,
+				Password:         cfg.Password,
+				Token:            cfg.Token,
+				Url:              cfg.Url,
+			},
+		}, nil
+	case BackendTestRail:
+		return &TestRail{
+			HttpClientConfig: clientCfg,
+			ProjectId:        cfg.ProjectId,
+			SuiteId:          cfg.SuiteId,
+			UserName:         cfg.UserName,
+			Password:         cfg.Password,
+			Url:              cfg.Url,
+		}, nil
+	case BackendFile:
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("backend %q requires dir", cfg.Backend)
+		}
+		return &FileStore{
+			ProjectKey: cfg.ProjectKey,
+			Dir:        cfg.Dir,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown backend %q", cfg.Backend)
+}
+
+func RootDir() string {
+	_, b, _, ok := runtime.Caller(0)
+	if !ok {
+		return ""
+	}
+	d := path.Join(path.Dir(b))
+	return filepath.Dir(d)
+}
@@ -0,0 +1,349 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type pushTestsSuite struct {
+	suite.Suite
+	jira *fakeJira
+	data *TestData
+}
+
+const (
+	fakeProjectKey = "OMNI"
+	fakeApiName    = "GetDemandPlanning"
+	fakeFolder     = "demand_planning"
+)
+
+func TestPushTests(t *testing.T) {
+	suite.Run(t, &pushTestsSuite{})
+}
+
+func (s *pushTestsSuite) SetupTest() {
+	s.jira = newFakeJira(fakeProjectKey)
+	s.data = NewTestData(s.jira.client())
+	s.T().Setenv(removeOldTestEnv, "")
+}
+
+func (s *pushTestsSuite) TearDownTest() {
+	s.jira.Close()
+}
+
+func (s *pushTestsSuite) cases(names ...string) []*TestCase {
+	var testCases []*TestCase
+	for i, name := range names {
+		status := "Pass"
+		if i%2 == 1 {
+			status = "Fail"
+		}
+		testCases = append(testCases, &TestCase{Name: name, Status: status})
+	}
+	return testCases
+}
+
+// cycleResults lists the "name: status" of the cycle items, in the cycle order.
+func (s *pushTestsSuite) cycleResults(cycleKey string) []string {
+	cycle := s.jira.testCycle(cycleKey)
+	if cycle == nil {
+		return nil
+	}
+	var results []string
+	for _, item := range cycle.Items {
+		name := ""
+		if testCase := s.jira.testCase(item.TestCaseKey); testCase != nil {
+			name = testCase.Name
+		}
+		results = append(results, fmt.Sprintf("%s: %s", name, item.Status))
+	}
+	return results
+}
+
+func (s *pushTestsSuite) TestGetIssue() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open", Parent: "OMNI-2"})
+	s.jira.addIssue("OMNI-2", &fakeIssue{Status: "Open", Parent: "OMNI-3"})
+	s.jira.addIssue("OMNI-3", &fakeIssue{Status: "In Progress", Epic: "Demand planning"})
+
+	issue, err := s.jira.client().GetIssue("OMNI-1")
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), &Issue{Key: "OMNI-1", Status: "Open", Epic: "Demand planning"}, issue)
+
+	issue, err = s.jira.client().GetIssue("OMNI-404")
+	assert.Nil(s.T(), issue)
+	var reqErr *RequestError
+	assert.True(s.T(), errors.As(err, &reqErr))
+	assert.Equal(s.T(), http.StatusNotFound, reqErr.StatusCode)
+}
+
+func (s *pushTestsSuite) TestAuth() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+
+	_, err := s.jira.client().GetIssue("OMNI-1")
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "Basic dXNlcjpwYXNzd29yZA==", s.jira.authorization())
+
+	jira := s.jira.client()
+	jira.Token = "personal-access-token"
+	_, err = jira.GetIssue("OMNI-1")
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "Bearer personal-access-token", s.jira.authorization())
+}
+
+func (s *pushTestsSuite) TestLoadEnv() {
+	s.T().Setenv(jiraUrlEnv, s.jira.URL)
+	s.T().Setenv(jiraTokenEnv, "env-token")
+	cfg := &IssueManagerConfig{Url: "https://jira.teko.vn", ProjectKey: fakeProjectKey, UserName: "user", Password: "password"}
+	cfg.loadEnv()
+	assert.Equal(s.T(), &IssueManagerConfig{Url: s.jira.URL, ProjectKey: fakeProjectKey, UserName: "user", Password: "password", Token: "env-token"}, cfg)
+}
+
+func (s *pushTestsSuite) TestRetry() {
+	route := "POST /rest/atm/1.0/testcase"
+	s.jira.failNext(route, http.StatusTooManyRequests, http.StatusTooManyRequests)
+
+	key, err := s.jira.client().CreateTest(&TestCase{Name: "retried"})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "OMNI-T1", key)
+	assert.Equal(s.T(), 3, s.jira.requestCount(route))
+}
+
+func (s *pushTestsSuite) TestRetry_ServerError() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+	getRoute := "GET /rest/api/2/issue/OMNI-1"
+	s.jira.failNext(getRoute, http.StatusServiceUnavailable)
+
+	_, err := s.jira.client().GetIssue("OMNI-1")
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, s.jira.requestCount(getRoute))
+
+	// A create may have been applied before the server failed, it is not sent again
+	createRoute := "POST /rest/atm/1.0/testcase"
+	s.jira.failNext(createRoute, http.StatusServiceUnavailable)
+	_, err = s.jira.client().CreateTest(&TestCase{Name: "not retried"})
+	var reqErr *RequestError
+	assert.True(s.T(), errors.As(err, &reqErr))
+	assert.Equal(s.T(), 1, s.jira.requestCount(createRoute))
+}
+
+func (s *pushTestsSuite) TestRetry_Disabled() {
+	route := "POST /rest/atm/1.0/testcase"
+	s.jira.failNext(route, http.StatusTooManyRequests)
+
+	jira := s.jira.client()
+	jira.RetryCount = NoRetry
+	_, err := jira.CreateTest(&TestCase{Name: "not retried"})
+	var reqErr *RequestError
+	assert.True(s.T(), errors.As(err, &reqErr))
+	assert.Equal(s.T(), http.StatusTooManyRequests, reqErr.StatusCode)
+	assert.Equal(s.T(), 1, s.jira.requestCount(route))
+}
+
+func (s *pushTestsSuite) TestRetry_ClientError() {
+	route := "POST /rest/atm/1.0/testrun"
+	s.jira.failNext(route, http.StatusUnauthorized)
+
+	key, err := s.jira.client().CreateTestCycle(&TestCycle{Name: "unauthorized"})
+	assert.Empty(s.T(), key)
+	var reqErr *RequestError
+	assert.True(s.T(), errors.As(err, &reqErr))
+	assert.Equal(s.T(), http.StatusUnauthorized, reqErr.StatusCode)
+	assert.Equal(s.T(), 1, s.jira.requestCount(route))
+}
+
+func (s *pushTestsSuite) TestRateLimit() {
+	jira := s.jira.client()
+	jira.RateLimit = 50
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		_, err := jira.CreateTest(&TestCase{Name: fmt.Sprintf("case %d", i)})
+		assert.Nil(s.T(), err)
+	}
+	assert.GreaterOrEqual(s.T(), time.Since(start), 100*time.Millisecond)
+}
+
+func (s *pushTestsSuite) TestCreateFolder() {
+	err := s.jira.client().CreateFolder("/HN7/API/SC/demand_planning")
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []*Folder{
+		{ProjectKey: fakeProjectKey, Name: "/HN7/API/SC/demand_planning", Type: "TEST_CASE"},
+		{ProjectKey: fakeProjectKey, Name: "/HN7/API/SC/demand_planning", Type: "TEST_RUN"},
+	}, s.jira.createdFolders())
+}
+
+func (s *pushTestsSuite) TestPushTests_SkipClosedIssue() {
+	for _, status := range []string{"Closed", "DONE"} {
+		s.Run(status, func() {
+			issueKey := fmt.Sprintf("OMNI-%s", status)
+			s.jira.addIssue(issueKey, &fakeIssue{Status: status})
+			err := s.data.PushTests(issueKey, fakeApiName, fakeFolder, s.cases("happy case"))
+			assert.Nil(s.T(), err)
+			assert.Empty(s.T(), s.jira.testCaseKeys())
+			assert.Empty(s.T(), s.jira.testCycleKeys())
+			assert.Empty(s.T(), s.jira.createdFolders())
+		})
+	}
+}
+
+func (s *pushTestsSuite) TestPushTests_HappyCase() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open", Parent: "OMNI-2"})
+	s.jira.addIssue("OMNI-2", &fakeIssue{Status: "Open", Epic: "Demand planning"})
+
+	err := s.data.PushTests("OMNI-1", fakeApiName, fakeFolder, s.cases("happy case", "error case"))
+	assert.Nil(s.T(), err)
+
+	folder := fmt.Sprintf("%s/%s", JiraBaseTestFolder, fakeFolder)
+	assert.Len(s.T(), s.jira.createdFolders(), 2)
+	assert.Len(s.T(), s.jira.testCaseKeys(), 2)
+	for _, key := range s.jira.testCaseKeys() {
+		testCase := s.jira.testCase(key)
+		assert.Equal(s.T(), []string{"OMNI-1"}, testCase.IssueLinks)
+		assert.Equal(s.T(), folder, testCase.Folder)
+		assert.Equal(s.T(), fakeProjectKey, testCase.ProjectKey)
+		assert.Equal(s.T(), "Draft", testCase.Status)
+	}
+
+	assert.Equal(s.T(), []string{"OMNI-C3"}, s.jira.testCycleKeys())
+	cycle := s.jira.testCycle("OMNI-C3")
+	assert.Equal(s.T(), "[Demand planning][OMNI-1]GetDemandPlanning", cycle.Name)
+	assert.Equal(s.T(), folder, cycle.Folder)
+	assert.Equal(s.T(), []string{"happy case: Pass", "error case: Fail"}, s.cycleResults("OMNI-C3"))
+	assert.Empty(s.T(), s.jira.deletedKeys())
+}
+
+func (s *pushTestsSuite) TestPushTests_ExecutionDetails() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+	testCases := s.cases("happy case", "error case")
+	testCases[0].ExecutionTime = 1500
+	testCases[1].ExecutionTime = 20
+	testCases[1].Comment = "expected: 1, actual: 2"
+	testCases[1].Logs = "request: {}\nresponse: {}\n"
+
+	err := s.data.PushTests("OMNI-1", fakeApiName, fakeFolder, testCases)
+	assert.Nil(s.T(), err)
+
+	cycleKeys := s.jira.testCycleKeys()
+	assert.Len(s.T(), cycleKeys, 1)
+	cycle := s.jira.testCycle(cycleKeys[0])
+	details := make(map[string]*TestCycleItem)
+	for _, item := range cycle.Items {
+		details[s.jira.testCase(item.TestCaseKey).Name] = item
+	}
+	assert.Equal(s.T(), int64(1500), details["happy case"].ExecutionTime)
+	assert.Empty(s.T(), details["happy case"].Comment)
+	assert.Nil(s.T(), s.jira.attachments(cycleKeys[0], details["happy case"].TestCaseKey))
+	assert.Equal(s.T(), int64(20), details["error case"].ExecutionTime)
+	assert.Equal(s.T(), "expected: 1, actual: 2", details["error case"].Comment)
+	errorKey := details["error case"].TestCaseKey
+	assert.Equal(s.T(), map[string]string{errorKey + ".log": "request: {}\nresponse: {}\n"}, s.jira.attachments(cycleKeys[0], errorKey))
+}
+
+func (s *pushTestsSuite) TestPushTests_KeepOrder() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+	var names, want []string
+	for i := 0; i < 50; i++ {
+		names = append(names, fmt.Sprintf("case %d", i))
+	}
+	testCases := s.cases(names...)
+	for _, testCase := range testCases {
+		want = append(want, fmt.Sprintf("%s: %s", testCase.Name, testCase.Status))
+	}
+
+	err := s.data.PushTests("OMNI-1", fakeApiName, fakeFolder, testCases)
+	assert.Nil(s.T(), err)
+	cycleKeys := s.jira.testCycleKeys()
+	assert.Len(s.T(), cycleKeys, 1)
+	assert.Equal(s.T(), want, s.cycleResults(cycleKeys[0]))
+}
+
+func (s *pushTestsSuite) TestPushTests_NoWorkers() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+	s.data.workers = 0
+
+	err := s.data.PushTests("OMNI-1", fakeApiName, fakeFolder, s.cases("happy case", "error case"))
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{"happy case: Pass", "error case: Fail"}, s.cycleResults("OMNI-C3"))
+}
+
+func (s *pushTestsSuite) TestPushTests_ReuseExistingTests() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+	reusedKey := s.jira.addTestCase(&TestCase{Name: "happy case", IssueLinks: []string{"OMNI-1"}})
+	staleKey := s.jira.addTestCase(&TestCase{Name: "stale case", IssueLinks: []string{"OMNI-1"}})
+
+	err := s.data.PushTests("OMNI-1", fakeApiName, fakeFolder, s.cases("happy case", "new case"))
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{reusedKey}, s.jira.updatedKeys())
+	assert.Empty(s.T(), s.jira.deletedKeys())
+	assert.Equal(s.T(), []string{reusedKey, staleKey, "OMNI-T3"}, s.jira.testCaseKeys())
+	assert.Equal(s.T(), "Draft", s.jira.testCase(reusedKey).Status)
+	assert.Equal(s.T(), []string{"happy case: Pass", "new case: Fail"}, s.cycleResults("OMNI-C4"))
+}
+
+func (s *pushTestsSuite) TestPushTests_RemoveOldTests() {
+	s.T().Setenv(removeOldTestEnv, "1")
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+	s.jira.addIssue("OMNI-2", &fakeIssue{Status: "Open"})
+	oldKey := s.jira.addTestCase(&TestCase{Name: "old case", IssueLinks: []string{"OMNI-1"}})
+	otherKey := s.jira.addTestCase(&TestCase{Name: "other issue", IssueLinks: []string{"OMNI-2"}})
+
+	err := s.data.PushTests("OMNI-1", fakeApiName, fakeFolder, s.cases("first suite"))
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{oldKey}, s.jira.deletedKeys())
+	assert.Equal(s.T(), []string{otherKey, "OMNI-T3"}, s.jira.testCaseKeys())
+	assert.Equal(s.T(), []string{"OMNI-C4"}, s.jira.testCycleKeys())
+
+	// A second suite of the same issue replaces the previous cycle and reuses the first suite cases
+	err = s.data.PushTests("OMNI-1", fakeApiName, fakeFolder, s.cases("second suite"))
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{oldKey, "OMNI-C4"}, s.jira.deletedKeys())
+	assert.Equal(s.T(), []string{"OMNI-T3"}, s.jira.updatedKeys())
+	assert.Equal(s.T(), []string{otherKey, "OMNI-T3", "OMNI-T5"}, s.jira.testCaseKeys())
+	assert.Equal(s.T(), []string{"OMNI-C6"}, s.jira.testCycleKeys())
+	assert.Equal(s.T(), []string{"first suite: Pass", "second suite: Pass"}, s.cycleResults("OMNI-C6"))
+}
+
+func (s *pushTestsSuite) TestPushTests_AggregateErrors() {
+	s.jira.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+	s.jira.failNext("POST /rest/atm/1.0/testcase", http.StatusUnauthorized)
+	s.jira.failNext("POST /rest/atm/1.0/testrun", http.StatusBadRequest)
+
+	err := s.data.PushTests("OMNI-1", fakeApiName, fakeFolder, s.cases("first case", "second case"))
+	var pushErr *PushError
+	assert.True(s.T(), errors.As(err, &pushErr))
+	assert.Equal(s.T(), "OMNI-1", pushErr.IssueKey)
+	assert.Len(s.T(), pushErr.Errs, 2)
+	var reqErr *RequestError
+	assert.True(s.T(), errors.As(pushErr.Errs[0], &reqErr))
+	assert.Equal(s.T(), http.StatusUnauthorized, reqErr.StatusCode)
+	assert.Len(s.T(), s.jira.testCaseKeys(), 1)
+	assert.Empty(s.T(), s.jira.testCycleKeys())
+}
+
+func (s *pushTestsSuite) TestPushTests_IssueNotFound() {
+	err := s.data.PushTests("OMNI-404", fakeApiName, fakeFolder, s.cases("happy case"))
+	var reqErr *RequestError
+	assert.True(s.T(), errors.As(err, &reqErr))
+	assert.Equal(s.T(), http.StatusNotFound, reqErr.StatusCode)
+	assert.Empty(s.T(), s.jira.testCaseKeys())
+}
+
+func TestRetryRequest_TransportError(t *testing.T) {
+	post := &resty.Response{Request: &resty.Request{Method: http.MethodPost}}
+	get := &resty.Response{Request: &resty.Request{Method: http.MethodGet}}
+	refused := &url.Error{Op: "Post", URL: "https://jira.teko.vn", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	reset := &url.Error{Op: "Post", URL: "https://jira.teko.vn", Err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}}
+
+	assert.True(t, retryRequest(post, refused))
+	assert.False(t, retryRequest(post, reset))
+	assert.True(t, retryRequest(get, reset))
+	assert.False(t, retryRequest(nil, refused))
+}
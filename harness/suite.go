@@ -0,0 +1,298 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type (
+	// Suite is the part of the integration test suites shared by the services: it keeps the
+	// results of the tests to push them to the issue manager and write the reports.
+	Suite struct {
+		mu sync.RWMutex
+		suite.Suite
+		suite.TearDownAllSuite
+		suite.SetupAllSuite
+		suiteName string
+		pkgPath   string
+		start     time.Time
+		IssuesKey string
+		Folder    string
+		ApiName   string
+		keys      []string
+		tests     map[string][]*testInfo
+		records   map[string]*testRecord
+		output    *outputCapture
+		DbName    string
+		Context   context.Context
+	}
+
+	testInfo struct {
+		name     string
+		status   string
+		run      bool
+		duration time.Duration
+		failure  string
+		logs     string
+	}
+)
+
+const (
+	submitTestEnv    = "submit_test"
+	removeOldTestEnv = "remove_old_test"
+)
+
+func (s *Suite) Run(name string, subtest func()) bool {
+	oldT := s.T()
+	var subT *testing.T
+	defer func() {
+		s.endRun(oldT, subT, name)
+	}()
+	return oldT.Run(name, func(t *testing.T) {
+		subT = t
+		s.SetT(t)
+		s.startRecord(t)
+		subtest()
+	})
+}
+
+func (s *Suite) endRun(t *testing.T, subT *testing.T, name string) {
+	s.SetT(t)
+	testName := fmt.Sprintf("%s/%s", t.Name(), name)
+	item := &testInfo{status: testStatus(t)}
+	if subT != nil {
+		// Only the subtest itself decides its status, the parent also fails when a sibling fails
+		item.status = testStatus(subT)
+		s.endRecord(subT, item)
+	}
+	s.addTest(testName, true, item)
+}
+
+func (s *Suite) SetupSuite() {
+	s.suiteName = s.T().Name()
+	s.pkgPath = testPackage()
+	s.start = time.Now()
+	s.keys = strings.Split(s.IssuesKey, ",")
+	s.tests = make(map[string][]*testInfo)
+	if os.Getenv(submitTestEnv) == "1" || os.Getenv(testReportDirEnv) != "" {
+		s.captureTestOutput()
+	}
+}
+
+func (s *Suite) TearDownSuite() {
+	s.stopTestOutput()
+	submit := os.Getenv("submit_test")
+	if submit == "1" {
+		for issueKey, tests := range s.tests {
+			runTests := s.getRunTests(tests)
+			if err := s.pushTests(issueKey, runTests); err != nil {
+				s.T().Errorf("could not push tests: %v", err)
+			}
+		}
+	}
+	if dir := os.Getenv(testReportDirEnv); dir != "" {
+		if err := s.writeReports(dir); err != nil {
+			s.T().Errorf("could not write test reports: %v", err)
+		}
+	}
+}
+
+func (s *Suite) AfterTest(_, testName string) {
+	item := &testInfo{status: testStatus(s.T())}
+	s.endRecord(s.T(), item)
+	s.addTest(testName, false, item)
+}
+
+func testStatus(t *testing.T) string {
+	if t.Failed() {
+		return "Fail"
+	}
+	return "Pass"
+}
+
+func (s *Suite) getRunTests(tests []*testInfo) []*testInfo {
+	var runTests []*testInfo
+	for _, t := range tests {
+		if t.run {
+			runTests = append(runTests, t)
+		}
+	}
+	for _, t := range tests {
+		if t.run {
+			continue
+		}
+		run := false
+		for _, rt := range runTests {
+			if strings.Contains(rt.name, fmt.Sprintf("%s/", t.name)) {
+				run = true
+				break
+			}
+		}
+		if !run {
+			runTests = append(runTests, t)
+		}
+	}
+	return runTests
+}
+
+func (s *Suite) pushTests(issueKey string, tests []*testInfo) error {
+	pushTests := make([]*TestCase, 0, len(tests))
+	if s.IssuesKey == "" {
+		s.IssuesKey = os.Getenv("issue_key")
+	}
+	if s.IssuesKey == "" {
+		panic("could not found IssuesKey")
+	}
+	if s.Folder == "" {
+		s.Folder = s.IssuesKey
+	}
+	if s.ApiName == "" {
+		s.ApiName = s.IssuesKey
+	}
+	for _, t := range tests {
+		name := strings.Replace(t.name, fmt.Sprintf("%s/", s.suiteName), "", 1)
+		if s.ApiName != "" && strings.Contains(name, "/") {
+			a := strings.Split(name, "/")
+			name = strings.Replace(name, a[0], "", 1)
+			name = fmt.Sprintf("[%s]", s.ApiName) + strings.ReplaceAll(name, "/", "-")
+		} else {
+			name = strings.Replace(name, "Test", "", 1)
+		}
+		pushTests = append(pushTests, &TestCase{
+			Name:          name,
+			Status:        t.status,
+			ExecutionTime: t.duration.Milliseconds(),
+			Comment:       t.failure,
+			Logs:          t.logs,
+		})
+	}
+	return GetTest().PushTests(issueKey, s.ApiName, s.Folder, pushTests)
+}
+
+func (s *Suite) addTest(testName string, run bool, item *testInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item.name = testName
+	item.run = run
+	checkName := testName + "/"
+	for _, issue := range s.keys {
+		oldTests, exist := s.tests[issue]
+		var tests []*testInfo
+		if exist {
+			// Check whether test has children
+			isParent := false
+			for _, t := range oldTests {
+				if strings.Contains(t.name, checkName) {
+					isParent = true
+				}
+				// Move parent testcase if a child testcase is added
+				if !strings.Contains(testName, t.name+"/") {
+					tests = append(tests, t)
+				}
+			}
+			if !isParent {
+				tests = append(tests, item)
+			}
+		} else {
+			tests = append(tests, item)
+		}
+		s.tests[issue] = tests
+	}
+}
+
+func (s *Suite) TruncateDatabase(db *gorm.DB, tableName string) error {
+	if IsLocalEnv() {
+		s.DeleteAllTables(db, tableName)
+		s.ResetAutoIncrement(db, tableName)
+		return nil
+	}
+	return db.Exec(fmt.Sprintf("TRUNCATE TABLE  %s;", tableName)).Error
+
+}
+
+func (s *Suite) TruncateDatabases(db *gorm.DB, tableNames ...string) {
+	for _, tableName := range tableNames {
+		err := s.TruncateDatabase(db, tableName)
+		if err != nil {
+			panic(fmt.Sprintf("Cann't truncate table %s", tableName))
+		}
+	}
+}
+
+func (s *Suite) DeleteAllTables(db *gorm.DB, tNames ...string) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range tNames {
+			e := tx.Exec(fmt.Sprintf("DELETE from  %s", name)).Error
+			if e != nil {
+				return e
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.T().Fatalf(fmt.Sprintf("Can't delete %v", err.Error()))
+	}
+}
+
+func (s *Suite) ResetAutoIncrement(db *gorm.DB, tName string) {
+	err := db.Exec(fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = 1", tName)).Error
+	if err != nil {
+		s.T().Fatalf(fmt.Sprintf("Can't reset %v", err.Error()))
+	}
+}
+
+func RunSuite(t *testing.T, s suite.TestingSuite, submitJiraTest bool, removeOldJiraTest bool) {
+	if err := configEnv(submitJiraTest, removeOldJiraTest); err != nil {
+		t.Fatal("Can't create environment")
+	}
+	suite.Run(t, s)
+	if err := unConfigEnv(); err != nil {
+		t.Fatal("Can't unset environment")
+	}
+}
+
+func configEnv(submitJiraTest bool, removeOldJiraTest bool) error {
+	// The test will be pushed automatically to jira after test if env "submit_test"
+	// is set to "1". If you don't want to push automatically, just comment this out
+	submitTest := "0"
+	if submitJiraTest {
+		submitTest = "1"
+	}
+	err := os.Setenv(submitTestEnv, submitTest)
+	if err != nil {
+		return err
+	}
+
+	// Set "remove_old_test" to "1" will delete all the older testcase in the issue before
+	removeOldTest := "0"
+	if removeOldJiraTest {
+		removeOldTest = "1"
+	}
+	err = os.Setenv(removeOldTestEnv, removeOldTest)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func unConfigEnv() error {
+	err := os.Unsetenv(submitTestEnv)
+	if err != nil {
+		return err
+	}
+	err = os.Unsetenv(removeOldTestEnv)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
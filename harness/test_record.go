@@ -0,0 +1,104 @@
+package harness
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	// testRecord is what a running test collected so far, keyed by testing.T Name.
+	testRecord struct {
+		start   time.Time
+		failure string
+		logs    strings.Builder
+	}
+	// failureRecorder forwards to the test while keeping the first failure message.
+	failureRecorder struct {
+		*testing.T
+		suite *Suite
+	}
+)
+
+func (r *failureRecorder) Errorf(format string, args ...interface{}) {
+	r.T.Helper()
+	r.suite.recordFailure(r.T, fmt.Sprintf(format, args...))
+	r.T.Errorf(format, args...)
+}
+
+// unrecordedFailure is the comment of a failed test whose message went straight to testing.T,
+// through assert.Equal(s.T(), ...) or goldie for instance.
+const unrecordedFailure = "failed outside of the suite assertions, see the test output"
+
+// SetT also points the assertions of the suite, such as s.Equal, at the failure recorder.
+func (s *Suite) SetT(t *testing.T) {
+	s.Suite.SetT(t)
+	s.Suite.Assertions = assert.New(&failureRecorder{T: t, suite: s})
+}
+
+// Assert returns assertions whose failure messages are pushed as the test comment.
+func (s *Suite) Assert() *assert.Assertions {
+	return assert.New(&failureRecorder{T: s.T(), suite: s})
+}
+
+// Require returns assertions whose failure messages are pushed as the test comment.
+func (s *Suite) Require() *require.Assertions {
+	return require.New(&failureRecorder{T: s.T(), suite: s})
+}
+
+// Logf logs to the current test and keeps the line to be attached to its test result.
+func (s *Suite) Logf(format string, args ...interface{}) {
+	t := s.T()
+	t.Helper()
+	line := fmt.Sprintf(format, args...)
+	s.mu.Lock()
+	if record, ok := s.records[t.Name()]; ok {
+		record.logs.WriteString(line)
+		record.logs.WriteString("\n")
+	}
+	s.mu.Unlock()
+	t.Log(line)
+}
+
+func (s *Suite) BeforeTest(_, _ string) {
+	s.startRecord(s.T())
+}
+
+func (s *Suite) startRecord(t *testing.T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records == nil {
+		s.records = make(map[string]*testRecord)
+	}
+	s.records[t.Name()] = &testRecord{start: time.Now()}
+}
+
+func (s *Suite) recordFailure(t *testing.T, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[t.Name()]; ok && record.failure == "" {
+		record.failure = strings.TrimSpace(message)
+	}
+}
+
+// endRecord removes the record of t and fills item with it.
+func (s *Suite) endRecord(t *testing.T, item *testInfo) {
+	s.flushTestOutput()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[t.Name()]
+	if !ok {
+		return
+	}
+	delete(s.records, t.Name())
+	item.duration = time.Since(record.start)
+	item.failure = record.failure
+	if item.failure == "" && t.Failed() {
+		item.failure = unrecordedFailure
+	}
+	item.logs = record.logs.String()
+}
@@ -0,0 +1,92 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// recordSuiteEnv makes TestRecordSuite run its failing tests, it is only set in the process
+// started by TestSuite_RecordFailures.
+const recordSuiteEnv = "HARNESS_RECORD_SUITE"
+
+type recordSuite struct {
+	Suite
+}
+
+func TestRecordSuite(t *testing.T) {
+	if os.Getenv(recordSuiteEnv) == "" {
+		t.Skip("run by TestSuite_RecordFailures")
+	}
+	suite.Run(t, &recordSuite{Suite: Suite{IssuesKey: "OMNI-1"}})
+}
+
+func (s *recordSuite) TestPlainAssert() {
+	s.Logf("request: %s", "{}")
+	assert.Equal(s.T(), 1, 2)
+}
+
+func (s *recordSuite) TestSuiteAssert() {
+	s.Equal(1, 2)
+}
+
+func (s *recordSuite) TestServiceOutput() {
+	fmt.Println("service: request received")
+	fmt.Fprintln(os.Stderr, "service: request failed")
+}
+
+func (s *recordSuite) TestRun() {
+	s.Run("plain assert", func() {
+		assert.Equal(s.T(), 1, 2)
+	})
+	s.Run("pass", func() {
+		s.Equal(1, 1)
+	})
+}
+
+func TestSuite_RecordFailures(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRecordSuite$", "-test.count=1")
+	cmd.Env = append(os.Environ(), recordSuiteEnv+"=1", testReportDirEnv+"="+dir, submitTestEnv+"=0")
+	// The suite fails on purpose, only its report matters
+	_ = cmd.Run()
+
+	data, err := os.ReadFile(filepath.Join(dir, reportName(testPackage(), "TestRecordSuite")+".json"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	report := &TestReport{}
+	assert.Nil(t, json.Unmarshal(data, report))
+	cases := make(map[string]*TestReportCase)
+	for _, c := range report.Cases {
+		cases[c.Name] = c
+	}
+	if assert.Contains(t, cases, "TestPlainAssert") {
+		assert.Equal(t, "Fail", cases["TestPlainAssert"].Status)
+		assert.Equal(t, unrecordedFailure, cases["TestPlainAssert"].Failure)
+		assert.Equal(t, "request: {}\n", cases["TestPlainAssert"].Logs)
+	}
+	if assert.Contains(t, cases, "TestSuiteAssert") {
+		assert.Equal(t, "Fail", cases["TestSuiteAssert"].Status)
+		assert.Contains(t, cases["TestSuiteAssert"].Failure, "Not equal")
+	}
+	if assert.Contains(t, cases, "TestServiceOutput") {
+		assert.Equal(t, "Pass", cases["TestServiceOutput"].Status)
+		assert.Contains(t, cases["TestServiceOutput"].Logs, "service: request received\n")
+		assert.Contains(t, cases["TestServiceOutput"].Logs, "service: request failed\n")
+	}
+	if assert.Contains(t, cases, "TestRun/plain assert") {
+		assert.Equal(t, "Fail", cases["TestRun/plain assert"].Status)
+		assert.Equal(t, unrecordedFailure, cases["TestRun/plain assert"].Failure)
+	}
+	if assert.Contains(t, cases, "TestRun/pass") {
+		assert.Equal(t, "Pass", cases["TestRun/pass"].Status)
+		assert.Empty(t, cases["TestRun/pass"].Failure)
+	}
+}
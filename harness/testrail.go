@@ -0,0 +1,387 @@
+package harness
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type (
+	// TestRail talks to the TestRail api v2. TestRail has no issue tracker, so issues
+	// are referenced through the "refs" field of cases and runs.
+	TestRail struct {
+		HttpClientConfig
+		ProjectId int
+		SuiteId   int
+		UserName  string
+		Password  string
+		Url       string
+		client    *resty.Client
+		clientMu  sync.Mutex
+		sections  map[string]int
+		sectionMu sync.Mutex
+	}
+	TestRailCase struct {
+		Id        int    `json:"id,omitempty"`
+		Title     string `json:"title"`
+		SectionId int    `json:"section_id,omitempty"`
+		Refs      string `json:"refs"`
+	}
+	// TestRailLinks are the links of a page of the bulk endpoints, Next is empty on the last page.
+	TestRailLinks struct {
+		Next string `json:"next"`
+	}
+	TestRailCases struct {
+		Links TestRailLinks   `json:"_links"`
+		Cases []*TestRailCase `json:"cases"`
+	}
+	TestRailSection struct {
+		Id       int    `json:"id,omitempty"`
+		Name     string `json:"name"`
+		SuiteId  int    `json:"suite_id,omitempty"`
+		ParentId int    `json:"parent_id,omitempty"`
+	}
+	TestRailSections struct {
+		Links    TestRailLinks      `json:"_links"`
+		Sections []*TestRailSection `json:"sections"`
+	}
+	TestRailRun struct {
+		Id          int    `json:"id,omitempty"`
+		SuiteId     int    `json:"suite_id,omitempty"`
+		Name        string `json:"name"`
+		Refs        string `json:"refs"`
+		IncludeAll  bool   `json:"include_all"`
+		CaseIds     []int  `json:"case_ids"`
+		Description string `json:"description"`
+		IsCompleted bool   `json:"is_completed,omitempty"`
+	}
+	TestRailRuns struct {
+		Links TestRailLinks  `json:"_links"`
+		Runs  []*TestRailRun `json:"runs"`
+	}
+	TestRailResult struct {
+		Id       int    `json:"id,omitempty"`
+		CaseId   int    `json:"case_id"`
+		StatusId int    `json:"status_id"`
+		Comment  string `json:"comment,omitempty"`
+		Elapsed  string `json:"elapsed,omitempty"`
+	}
+	TestRailResults struct {
+		Results []*TestRailResult `json:"results"`
+	}
+)
+
+const (
+	testRailCasePrefix   = "C"
+	testRailRunPrefix    = "R"
+	testRailStatusPassed = 1
+	testRailStatusFailed = 5
+	// testRailIssueOpen and testRailIssueClosed are the statuses of the issues given by GetIssue
+	testRailIssueOpen   = "Open"
+	testRailIssueClosed = "Closed"
+)
+
+// testRailPage is a page of a bulk endpoint, such as get_cases.
+type testRailPage interface {
+	links() TestRailLinks
+}
+
+func (c *TestRailCases) links() TestRailLinks {
+	return c.Links
+}
+
+func (s *TestRailSections) links() TestRailLinks {
+	return s.Links
+}
+
+func (r *TestRailRuns) links() TestRailLinks {
+	return r.Links
+}
+
+func (r *TestRail) GetClient() *resty.Client {
+	r.clientMu.Lock()
+	defer r.clientMu.Unlock()
+	client := r.client
+	if client == nil {
+		client = r.initClient()
+		r.client = client
+	}
+	return client
+}
+
+func (r *TestRail) initClient() *resty.Client {
+	client := newHttpClient(r.HttpClientConfig)
+	testLog.AddSecret(r.Password)
+	client.SetBasicAuth(r.UserName, r.Password)
+	client.SetHeader("Content-Type", "application/json")
+	return client
+}
+
+func (r *TestRail) api(method string, args ...interface{}) string {
+	path := method
+	for _, arg := range args {
+		path = fmt.Sprintf("%s/%v", path, arg)
+	}
+	return fmt.Sprintf("%s/index.php?/api/v2/%s", r.Url, path)
+}
+
+// getPages requests url then follows the next links until the last page, read is given every
+// page, which is decoded into a new result.
+func (r *TestRail) getPages(url string, result func() testRailPage, read func(page testRailPage)) error {
+	client := r.GetClient()
+	for url != "" {
+		res, err := execute(client.R().SetResult(result()), resty.MethodGet, url)
+		if err != nil {
+			return err
+		}
+		page := res.Result().(testRailPage)
+		read(page)
+		url = ""
+		if next := page.links().Next; next != "" {
+			// The links are relative to the api, as in "/api/v2/get_cases/1&offset=250"
+			url = fmt.Sprintf("%s/index.php?%s", r.Url, next)
+		}
+	}
+	return nil
+}
+
+// GetIssue tells the status of issueKey from the runs which reference it: the issue is closed
+// once its latest run is closed. TestRail does not know about epics.
+func (r *TestRail) GetIssue(issueKey string) (*Issue, error) {
+	url := fmt.Sprintf("%s&refs_filter=%s", r.api("get_runs", r.ProjectId), issueKey)
+	var latest *TestRailRun
+	err := r.getPages(url, func() testRailPage {
+		return &TestRailRuns{}
+	}, func(page testRailPage) {
+		for _, run := range page.(*TestRailRuns).Runs {
+			if latest == nil || run.Id > latest.Id {
+				latest = run
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	issue := &Issue{Key: issueKey, Status: testRailIssueOpen}
+	if latest != nil && latest.IsCompleted {
+		issue.Status = testRailIssueClosed
+	}
+	return issue, nil
+}
+
+func (r *TestRail) ListTests(issueKey string) ([]*TestResult, error) {
+	url := r.api("get_cases", r.ProjectId)
+	if r.SuiteId != 0 {
+		url = fmt.Sprintf("%s&suite_id=%d", url, r.SuiteId)
+	}
+	url = fmt.Sprintf("%s&refs=%s", url, issueKey)
+	var tests []*TestResult
+	err := r.getPages(url, func() testRailPage {
+		return &TestRailCases{}
+	}, func(page testRailPage) {
+		for _, c := range page.(*TestRailCases).Cases {
+			tests = append(tests, &TestResult{
+				Key:  testRailKey(testRailCasePrefix, c.Id),
+				Name: c.Title,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tests, nil
+}
+
+func (r *TestRail) CreateTest(testCase *TestCase) (string, error) {
+	sectionId, err := r.sectionId(testCase.Folder)
+	if err != nil {
+		return "", err
+	}
+	client := r.GetClient()
+	req := client.R().
+		SetBody(&TestRailCase{
+			Title: testCase.Name,
+			Refs:  strings.Join(testCase.IssueLinks, ","),
+		}).
+		SetResult(&TestRailCase{})
+	resp, err := execute(req, resty.MethodPost, r.api("add_case", sectionId))
+	if err != nil {
+		return "", err
+	}
+	return testRailKey(testRailCasePrefix, resp.Result().(*TestRailCase).Id), nil
+}
+
+func (r *TestRail) UpdateTest(testKey string, testCase *TestCase) error {
+	id, err := testRailId(testRailCasePrefix, testKey)
+	if err != nil {
+		return err
+	}
+	client := r.GetClient()
+	req := client.R().SetBody(&TestRailCase{
+		Title: testCase.Name,
+		Refs:  strings.Join(testCase.IssueLinks, ","),
+	})
+	_, err = execute(req, resty.MethodPost, r.api("update_case", id))
+	return err
+}
+
+func (r *TestRail) DeleteTest(testKey string) error {
+	id, err := testRailId(testRailCasePrefix, testKey)
+	if err != nil {
+		return err
+	}
+	client := r.GetClient()
+	_, err = execute(client.R(), resty.MethodPost, r.api("delete_case", id))
+	return err
+}
+
+// CreateTestCycle creates a run limited to the cycle cases, then records one result per case.
+func (r *TestRail) CreateTestCycle(cycle *TestCycle) (string, error) {
+	run := &TestRailRun{
+		SuiteId:     r.SuiteId,
+		Name:        cycle.Name,
+		Refs:        cycle.IssueKey,
+		Description: cycle.Folder,
+	}
+	results := &TestRailResults{}
+	for _, item := range cycle.Items {
+		caseId, err := testRailId(testRailCasePrefix, item.TestCaseKey)
+		if err != nil {
+			return "", err
+		}
+		run.CaseIds = append(run.CaseIds, caseId)
+		results.Results = append(results.Results, &TestRailResult{
+			CaseId:   caseId,
+			StatusId: testRailStatus(item.Status),
+			Comment:  item.Comment,
+			Elapsed:  testRailElapsed(item.ExecutionTime),
+		})
+	}
+	client := r.GetClient()
+	resp, err := execute(client.R().SetBody(run).SetResult(&TestRailRun{}), resty.MethodPost, r.api("add_run", r.ProjectId))
+	if err != nil {
+		return "", err
+	}
+	runId := resp.Result().(*TestRailRun).Id
+	runKey := testRailKey(testRailRunPrefix, runId)
+	if len(results.Results) == 0 {
+		return runKey, nil
+	}
+	// Results are returned in the order they were added, that is the order of the cycle items
+	resp, err = execute(client.R().SetBody(results).SetResult([]*TestRailResult{}), resty.MethodPost, r.api("add_results_for_cases", runId))
+	if err != nil {
+		return runKey, err
+	}
+	added := *resp.Result().(*[]*TestRailResult)
+	for i, item := range cycle.Items {
+		if i >= len(added) {
+			break
+		}
+		for _, attachment := range item.Attachments {
+			req := client.R().SetFileReader("attachment", attachment.Name, bytes.NewReader(attachment.Data))
+			if _, err = execute(req, resty.MethodPost, r.api("add_attachment_to_result", added[i].Id)); err != nil {
+				return runKey, err
+			}
+		}
+	}
+	return runKey, nil
+}
+
+func (r *TestRail) DeleteTestCycle(cycleKey string) error {
+	id, err := testRailId(testRailRunPrefix, cycleKey)
+	if err != nil {
+		return err
+	}
+	client := r.GetClient()
+	_, err = execute(client.R(), resty.MethodPost, r.api("delete_run", id))
+	return err
+}
+
+// CreateFolder creates the nested sections of folder, reusing the sections that already exist.
+func (r *TestRail) CreateFolder(folder string) error {
+	_, err := r.sectionId(folder)
+	return err
+}
+
+func (r *TestRail) sectionId(folder string) (int, error) {
+	r.sectionMu.Lock()
+	defer r.sectionMu.Unlock()
+	if id, ok := r.sections[folder]; ok {
+		return id, nil
+	}
+	url := r.api("get_sections", r.ProjectId)
+	if r.SuiteId != 0 {
+		url = fmt.Sprintf("%s&suite_id=%d", url, r.SuiteId)
+	}
+	var existing []*TestRailSection
+	err := r.getPages(url, func() testRailPage {
+		return &TestRailSections{}
+	}, func(page testRailPage) {
+		existing = append(existing, page.(*TestRailSections).Sections...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	client := r.GetClient()
+	parentId := 0
+	for _, name := range strings.Split(folder, "/") {
+		if name == "" {
+			continue
+		}
+		var section *TestRailSection
+		for _, s := range existing {
+			if s.Name == name && s.ParentId == parentId {
+				section = s
+				break
+			}
+		}
+		if section == nil {
+			req := client.R().
+				SetBody(&TestRailSection{Name: name, SuiteId: r.SuiteId, ParentId: parentId}).
+				SetResult(&TestRailSection{})
+			resp, err := execute(req, resty.MethodPost, r.api("add_section", r.ProjectId))
+			if err != nil {
+				return 0, err
+			}
+			section = resp.Result().(*TestRailSection)
+		}
+		parentId = section.Id
+	}
+	if r.sections == nil {
+		r.sections = make(map[string]int)
+	}
+	r.sections[folder] = parentId
+	return parentId, nil
+}
+
+func testRailKey(prefix string, id int) string {
+	return fmt.Sprintf("%s%d", prefix, id)
+}
+
+func testRailId(prefix string, key string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(key, prefix))
+	if err != nil {
+		return 0, fmt.Errorf("invalid testrail key %q", key)
+	}
+	return id, nil
+}
+
+// testRailElapsed formats milliseconds as a TestRail timespan, which has a one second resolution.
+func testRailElapsed(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	seconds := (ms + 999) / 1000
+	return fmt.Sprintf("%ds", seconds)
+}
+
+func testRailStatus(status string) int {
+	if strings.ToLower(status) == "fail" {
+		return testRailStatusFailed
+	}
+	return testRailStatusPassed
+}
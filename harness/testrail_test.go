@@ -0,0 +1,380 @@
+package harness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// fakeTestRail is an in-memory TestRail with the api v2 endpoints used by TestRail.
+	fakeTestRail struct {
+		*fakeBackend
+		cases       map[int]*TestRailCase
+		sections    []*TestRailSection
+		runs        map[int]*TestRailRun
+		results     map[int][]*TestRailResult
+		attachments map[int]map[string]string
+		updated     []int
+		// pageSize is the number of items of the pages of the bulk endpoints, all of them when 0
+		pageSize int
+	}
+)
+
+func newFakeTestRail() *fakeTestRail {
+	f := &fakeTestRail{
+		fakeBackend: newFakeBackend(""),
+		cases:       make(map[int]*TestRailCase),
+		runs:        make(map[int]*TestRailRun),
+		results:     make(map[int][]*TestRailResult),
+		attachments: make(map[int]map[string]string),
+	}
+	f.Server = httptest.NewServer(f.withFailures(http.HandlerFunc(f.handleApi), testRailRoute))
+	return f
+}
+
+// testRailApi splits the "/api/v2/<method>/<arg>&<params>" query of r.
+func testRailApi(r *http.Request) (method string, arg int, params url.Values) {
+	parts := strings.SplitN(r.URL.RawQuery, "&", 2)
+	path := strings.Split(strings.TrimPrefix(parts[0], "/api/v2/"), "/")
+	method = path[0]
+	if len(path) > 1 {
+		arg, _ = strconv.Atoi(path[1])
+	}
+	if len(parts) > 1 {
+		params, _ = url.ParseQuery(parts[1])
+	}
+	return method, arg, params
+}
+
+// testRailRoute names the route of r as in failNext, such as "POST add_case".
+func testRailRoute(r *http.Request) string {
+	method, _, _ := testRailApi(r)
+	return r.Method + " " + method
+}
+
+func (f *fakeTestRail) client() *TestRail {
+	return &TestRail{
+		HttpClientConfig: HttpClientConfig{
+			RetryWaitTime: time.Millisecond,
+			RateLimit:     1000,
+		},
+		ProjectId: 1,
+		SuiteId:   2,
+		UserName:  "user",
+		Password:  "password",
+		Url:       f.URL,
+	}
+}
+
+func (f *fakeTestRail) nextId() int {
+	f.seq++
+	return f.seq
+}
+
+// addSection and addCase store the sections and cases of an earlier run.
+func (f *fakeTestRail) addSection(name string, parentId int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	section := &TestRailSection{Id: f.nextId(), Name: name, SuiteId: 2, ParentId: parentId}
+	f.sections = append(f.sections, section)
+	return section.Id
+}
+
+func (f *fakeTestRail) addCase(title string, sectionId int, refs string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := &TestRailCase{Id: f.nextId(), Title: title, SectionId: sectionId, Refs: refs}
+	f.cases[c.Id] = c
+	return c.Id
+}
+
+func (f *fakeTestRail) testCase(id int) *TestRailCase {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cases[id]
+}
+
+func (f *fakeTestRail) createdSections() []*TestRailSection {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*TestRailSection(nil), f.sections...)
+}
+
+func (f *fakeTestRail) updatedIds() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.updated...)
+}
+
+// resultAttachments returns the files attached to the result resultId, by name.
+func (f *fakeTestRail) resultAttachments(resultId int) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attachments[resultId]
+}
+
+func (f *fakeTestRail) run(id int) (*TestRailRun, []*TestRailResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.runs[id], f.results[id]
+}
+
+// completeRuns closes the runs of the issue refs, as a tester does once the issue is done.
+func (f *fakeTestRail) completeRuns(refs string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, run := range f.runs {
+		if run.Refs == refs {
+			run.IsCompleted = true
+		}
+	}
+}
+
+// page returns the range of the total items to send for the offset of r, with the link to the
+// next page when there is one.
+func (f *fakeTestRail) page(r *http.Request, total int) (from int, to int, links TestRailLinks) {
+	_, _, params := testRailApi(r)
+	if params == nil {
+		params = url.Values{}
+	}
+	from, _ = strconv.Atoi(params.Get("offset"))
+	if from > total {
+		from = total
+	}
+	to = total
+	if f.pageSize > 0 && from+f.pageSize < total {
+		to = from + f.pageSize
+		params.Set("offset", strconv.Itoa(to))
+		links.Next = strings.SplitN(r.URL.RawQuery, "&", 2)[0] + "&" + params.Encode()
+	}
+	return from, to, links
+}
+
+func (f *fakeTestRail) handleApi(w http.ResponseWriter, r *http.Request) {
+	method, arg, params := testRailApi(r)
+	if r.Method == http.MethodPost && method == "add_attachment_to_result" {
+		f.handleAttachment(w, r, arg)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err == nil && len(body) > 0 && !json.Valid(body) {
+		err = errors.New("invalid json body")
+	}
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+	decode := func(v interface{}) {
+		_ = json.Unmarshal(body, v)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method + " " + method {
+	case "GET get_cases":
+		cases := &TestRailCases{Cases: make([]*TestRailCase, 0)}
+		for id := 1; id <= f.seq; id++ {
+			if c, ok := f.cases[id]; ok && c.Refs == params.Get("refs") {
+				cases.Cases = append(cases.Cases, c)
+			}
+		}
+		from, to, links := f.page(r, len(cases.Cases))
+		writeJson(w, http.StatusOK, &TestRailCases{Links: links, Cases: cases.Cases[from:to]})
+	case "POST add_case":
+		c := &TestRailCase{}
+		decode(c)
+		c.Id = f.nextId()
+		c.SectionId = arg
+		f.cases[c.Id] = c
+		writeJson(w, http.StatusOK, c)
+	case "POST update_case":
+		c, ok := f.cases[arg]
+		if !ok {
+			writeJson(w, http.StatusBadRequest, map[string]interface{}{"error": "unknown case"})
+			return
+		}
+		decode(c)
+		f.updated = append(f.updated, arg)
+		writeJson(w, http.StatusOK, c)
+	case "POST delete_case":
+		delete(f.cases, arg)
+		writeJson(w, http.StatusOK, map[string]interface{}{})
+	case "GET get_sections":
+		from, to, links := f.page(r, len(f.sections))
+		writeJson(w, http.StatusOK, &TestRailSections{Links: links, Sections: f.sections[from:to]})
+	case "POST add_section":
+		section := &TestRailSection{}
+		decode(section)
+		section.Id = f.nextId()
+		f.sections = append(f.sections, section)
+		writeJson(w, http.StatusOK, section)
+	case "GET get_runs":
+		runs := make([]*TestRailRun, 0)
+		for id := 1; id <= f.seq; id++ {
+			if run, ok := f.runs[id]; ok && run.Refs == params.Get("refs_filter") {
+				runs = append(runs, run)
+			}
+		}
+		from, to, links := f.page(r, len(runs))
+		writeJson(w, http.StatusOK, &TestRailRuns{Links: links, Runs: runs[from:to]})
+	case "POST add_run":
+		run := &TestRailRun{}
+		decode(run)
+		run.Id = f.nextId()
+		f.runs[run.Id] = run
+		writeJson(w, http.StatusOK, run)
+	case "POST add_results_for_cases":
+		results := &TestRailResults{}
+		decode(results)
+		for _, result := range results.Results {
+			result.Id = f.nextId()
+		}
+		f.results[arg] = results.Results
+		writeJson(w, http.StatusOK, results.Results)
+	case "POST delete_run":
+		delete(f.runs, arg)
+		writeJson(w, http.StatusOK, map[string]interface{}{})
+	default:
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"error": "unknown method " + method})
+	}
+}
+
+func (f *fakeTestRail) handleAttachment(w http.ResponseWriter, r *http.Request, resultId int) {
+	file, header, err := r.FormFile("attachment")
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.attachments[resultId] == nil {
+		f.attachments[resultId] = make(map[string]string)
+	}
+	f.attachments[resultId][header.Filename] = string(data)
+	writeJson(w, http.StatusOK, map[string]interface{}{"attachment_id": resultId})
+}
+
+func TestTestRail_PushTests(t *testing.T) {
+	t.Setenv(removeOldTestEnv, "")
+	f := newFakeTestRail()
+	defer f.Close()
+	rootId := f.addSection("HN7", 0)
+	reusedId := f.addCase("happy case", rootId, "OMNI-1")
+
+	testCases := []*TestCase{
+		{Name: "happy case", Status: "Pass", ExecutionTime: 1500},
+		{Name: "error case", Status: "Fail", Comment: "expected: 1, actual: 2", Logs: "request: {}\n"},
+	}
+	err := NewTestData(f.client()).PushTests("OMNI-1", fakeApiName, fakeFolder, testCases)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []*TestRailSection{
+		{Id: 1, Name: "HN7", SuiteId: 2},
+		{Id: 3, Name: "API", SuiteId: 2, ParentId: 1},
+		{Id: 4, Name: "SC", SuiteId: 2, ParentId: 3},
+		{Id: 5, Name: fakeFolder, SuiteId: 2, ParentId: 4},
+	}, f.createdSections())
+	assert.Equal(t, []int{reusedId}, f.updatedIds())
+	assert.Equal(t, &TestRailCase{Id: reusedId, Title: "happy case", SectionId: rootId, Refs: "OMNI-1"}, f.testCase(reusedId))
+	assert.Equal(t, &TestRailCase{Id: 6, Title: "error case", SectionId: 5, Refs: "OMNI-1"}, f.testCase(6))
+
+	run, results := f.run(7)
+	if assert.NotNil(t, run) {
+		assert.Equal(t, "[][OMNI-1]GetDemandPlanning", run.Name)
+		assert.Equal(t, "OMNI-1", run.Refs)
+		assert.Equal(t, []int{reusedId, 6}, run.CaseIds)
+	}
+	assert.Equal(t, []*TestRailResult{
+		{Id: 8, CaseId: reusedId, StatusId: testRailStatusPassed, Elapsed: "2s"},
+		{Id: 9, CaseId: 6, StatusId: testRailStatusFailed, Comment: "expected: 1, actual: 2"},
+	}, results)
+	assert.Nil(t, f.resultAttachments(8))
+	assert.Equal(t, map[string]string{"C6.log": "request: {}\n"}, f.resultAttachments(9))
+}
+
+func TestTestRail_Pages(t *testing.T) {
+	f := newFakeTestRail()
+	defer f.Close()
+	f.pageSize = 2
+	rootId := f.addSection("HN7", 0)
+	apiId := f.addSection("API", rootId)
+	scId := f.addSection("SC", apiId)
+	for i := 0; i < 5; i++ {
+		f.addCase(fmt.Sprintf("case %d", i), scId, "OMNI-1")
+	}
+
+	client := f.client()
+	tests, err := client.ListTests("OMNI-1")
+	assert.Nil(t, err)
+	assert.Len(t, tests, 5)
+	assert.Equal(t, 3, f.requestCount("GET get_cases"))
+
+	// The sections of the last page are found instead of being created again
+	folderId, err := client.sectionId(JiraBaseTestFolder)
+	assert.Nil(t, err)
+	assert.Equal(t, scId, folderId)
+	assert.Equal(t, 0, f.requestCount("POST add_section"))
+}
+
+func TestTestRail_GetIssue(t *testing.T) {
+	t.Setenv(removeOldTestEnv, "")
+	f := newFakeTestRail()
+	defer f.Close()
+	client := f.client()
+
+	issue, err := client.GetIssue("OMNI-1")
+	assert.Nil(t, err)
+	assert.Equal(t, &Issue{Key: "OMNI-1", Status: testRailIssueOpen}, issue)
+
+	assert.Nil(t, NewTestData(client).PushTests("OMNI-1", fakeApiName, fakeFolder, []*TestCase{{Name: "happy case", Status: "Pass"}}))
+	runs := f.requestCount("POST add_run")
+	assert.Equal(t, 1, runs)
+	f.completeRuns("OMNI-1")
+
+	issue, err = client.GetIssue("OMNI-1")
+	assert.Nil(t, err)
+	assert.Equal(t, testRailIssueClosed, issue.Status)
+	// The tests of a closed issue are not pushed anymore
+	assert.Nil(t, NewTestData(client).PushTests("OMNI-1", fakeApiName, fakeFolder, []*TestCase{{Name: "happy case", Status: "Pass"}}))
+	assert.Equal(t, runs, f.requestCount("POST add_run"))
+}
+
+func TestTestRail_RequestError(t *testing.T) {
+	t.Setenv(removeOldTestEnv, "")
+	f := newFakeTestRail()
+	defer f.Close()
+	f.failNext("POST add_case", http.StatusBadRequest)
+
+	client := f.client()
+	err := NewTestData(client).PushTests("OMNI-1", fakeApiName, fakeFolder, []*TestCase{{Name: "happy case", Status: "Pass"}})
+	var reqErr *RequestError
+	if assert.True(t, errors.As(err, &reqErr)) {
+		assert.Equal(t, http.MethodPost, reqErr.Method)
+		assert.Equal(t, client.api("add_case", 4), reqErr.Url)
+		assert.Equal(t, http.StatusBadRequest, reqErr.StatusCode)
+		assert.Contains(t, reqErr.Body, "Bad Request")
+	}
+	assert.Equal(t, 1, f.requestCount("POST add_case"))
+	// The run is still created without the case that failed
+	run, results := f.run(5)
+	if assert.NotNil(t, run) {
+		assert.Empty(t, run.CaseIds)
+	}
+	assert.Empty(t, results)
+}
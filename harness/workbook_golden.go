@@ -0,0 +1,314 @@
+package harness
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v3"
+	"github.com/sebdah/goldie/v2"
+)
+
+type (
+	// WorkbookGolden compares a workbook with a pair of golden files: <name>.xlsx, which opens
+	// in a spreadsheet, and <name>.golden, its JSON snapshot which shows in reviews. Running the
+	// tests with -update writes both from the actual workbook.
+	WorkbookGolden struct {
+		// Dir holds the golden files, testdata by default
+		Dir string
+		// Sheets are the sheets compared, all of them when empty; the static sheets such as
+		// the instructions are better left out
+		Sheets          []string
+		Styles          bool
+		MergedCells     bool
+		NumberFormats   bool
+		DataValidations bool
+	}
+	// workbookSnapshot is what WorkbookGolden compares of a workbook.
+	workbookSnapshot struct {
+		Sheets []*sheetSnapshot `json:"sheets"`
+	}
+	// sheetSnapshot describes the used range of a sheet, the styles and number formats are
+	// keyed by cell and only kept for the cells which have one.
+	sheetSnapshot struct {
+		Name            string            `json:"name"`
+		Rows            [][]string        `json:"rows"`
+		MergedCells     []string          `json:"mergedCells,omitempty"`
+		NumberFormats   map[string]string `json:"numberFormats,omitempty"`
+		Styles          map[string]string `json:"styles,omitempty"`
+		DataValidations []string          `json:"dataValidations,omitempty"`
+	}
+)
+
+const (
+	defaultGoldenDir = "testdata"
+	// maxDiffLines is the number of differences reported for a workbook
+	maxDiffLines = 100
+)
+
+// Assert compares actual with the golden workbook name, cell by cell over the used range of
+// every compared sheet, then checks the .golden snapshot is up to date.
+func (g WorkbookGolden) Assert(t *testing.T, name string, actual *excelize.File) {
+	t.Helper()
+	actualSnapshot, err := g.snapshot(actual)
+	if err != nil {
+		t.Fatalf("could not read the actual workbook: %v", err)
+	}
+	xlsxPath := filepath.Join(g.dir(), name+".xlsx")
+	if isGoldenUpdate() {
+		if err = os.MkdirAll(filepath.Dir(xlsxPath), 0755); err != nil {
+			t.Fatalf("could not create %s: %v", filepath.Dir(xlsxPath), err)
+		}
+		if err = actual.SaveAs(xlsxPath); err != nil {
+			t.Fatalf("could not save %s: %v", xlsxPath, err)
+		}
+	} else {
+		expected, err := excelize.OpenFile(xlsxPath)
+		if err != nil {
+			t.Fatalf("could not open %s, run the test with -update to create it: %v", xlsxPath, err)
+		}
+		expectedSnapshot, err := g.snapshot(expected)
+		if err != nil {
+			t.Fatalf("could not read %s: %v", xlsxPath, err)
+		}
+		if diff := expectedSnapshot.diff(actualSnapshot); len(diff) > 0 {
+			t.Errorf("workbook differs from %s:\n%s", xlsxPath, strings.Join(diff, "\n"))
+			return
+		}
+	}
+	goldie.New(t, goldie.WithFixtureDir(g.dir())).AssertJson(t, name, actualSnapshot)
+}
+
+func (g WorkbookGolden) dir() string {
+	if g.Dir == "" {
+		return defaultGoldenDir
+	}
+	return g.Dir
+}
+
+// isGoldenUpdate tells whether the tests run with the -update flag of goldie.
+func isGoldenUpdate() bool {
+	update := flag.Lookup("update")
+	return update != nil && update.Value.String() == "true"
+}
+
+func (g WorkbookGolden) snapshot(file *excelize.File) (*workbookSnapshot, error) {
+	snapshot := &workbookSnapshot{}
+	for _, name := range file.GetSheetList() {
+		if len(g.Sheets) > 0 && !contains(g.Sheets, name) {
+			continue
+		}
+		sheet := &sheetSnapshot{Name: name}
+		rows, err := file.GetRows(name)
+		if err != nil {
+			return nil, err
+		}
+		sheet.Rows = rows
+		if g.MergedCells {
+			mergeCells, err := file.GetMergeCells(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, mergeCell := range mergeCells {
+				sheet.MergedCells = append(sheet.MergedCells, mergeCell.GetStartAxis()+":"+mergeCell.GetEndAxis())
+			}
+			sort.Strings(sheet.MergedCells)
+		}
+		if g.Styles || g.NumberFormats {
+			if err = g.cellStyles(file, sheet); err != nil {
+				return nil, err
+			}
+		}
+		if g.DataValidations {
+			validations, err := file.GetDataValidations(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, validation := range validations {
+				sheet.DataValidations = append(sheet.DataValidations, strings.Join([]string{
+					validation.Sqref, validation.Type, validation.Operator, validation.Formula1, validation.Formula2,
+				}, " "))
+			}
+			sort.Strings(sheet.DataValidations)
+		}
+		snapshot.Sheets = append(snapshot.Sheets, sheet)
+	}
+	return snapshot, nil
+}
+
+// cellStyles reads the styles and number formats of the cells of the used range.
+func (g WorkbookGolden) cellStyles(file *excelize.File, sheet *sheetSnapshot) error {
+	numberFormats := make(map[string]string)
+	styles := make(map[string]string)
+	columns := 0
+	for _, row := range sheet.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	for row := 1; row <= len(sheet.Rows); row++ {
+		for col := 1; col <= columns; col++ {
+			cell, err := excelize.CoordinatesToCellName(col, row)
+			if err != nil {
+				return err
+			}
+			styleID, err := file.GetCellStyle(sheet.Name, cell)
+			if err != nil {
+				return err
+			}
+			if styleID == 0 {
+				continue
+			}
+			style, err := file.GetStyle(styleID)
+			if err != nil {
+				return err
+			}
+			if style.CustomNumFmt != nil {
+				numberFormats[cell] = *style.CustomNumFmt
+			} else if style.NumFmt != 0 {
+				numberFormats[cell] = strconv.Itoa(style.NumFmt)
+			}
+			// The number format is compared on its own
+			style.NumFmt, style.CustomNumFmt, style.DecimalPlaces = 0, nil, nil
+			content, err := json.Marshal(style)
+			if err != nil {
+				return err
+			}
+			styles[cell] = string(content)
+		}
+	}
+	if g.NumberFormats && len(numberFormats) > 0 {
+		sheet.NumberFormats = numberFormats
+	}
+	if g.Styles && len(styles) > 0 {
+		sheet.Styles = styles
+	}
+	return nil
+}
+
+// diff lists the differences of actual with the expected snapshot, one line per cell.
+func (s *workbookSnapshot) diff(actual *workbookSnapshot) []string {
+	var lines []string
+	actualSheets := make(map[string]*sheetSnapshot)
+	for _, sheet := range actual.Sheets {
+		actualSheets[sheet.Name] = sheet
+	}
+	expectedSheets := make(map[string]bool)
+	for _, expected := range s.Sheets {
+		expectedSheets[expected.Name] = true
+		sheet, ok := actualSheets[expected.Name]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("sheet %q is missing", expected.Name))
+			continue
+		}
+		lines = append(lines, expected.diff(sheet)...)
+	}
+	for _, sheet := range actual.Sheets {
+		if !expectedSheets[sheet.Name] {
+			lines = append(lines, fmt.Sprintf("unexpected sheet %q", sheet.Name))
+		}
+	}
+	if len(lines) > maxDiffLines {
+		lines = append(lines[:maxDiffLines], fmt.Sprintf("... and %d more differences", len(lines)-maxDiffLines))
+	}
+	return lines
+}
+
+func (s *sheetSnapshot) diff(actual *sheetSnapshot) []string {
+	var lines []string
+	rows := len(s.Rows)
+	if len(actual.Rows) > rows {
+		rows = len(actual.Rows)
+	}
+	for row := 0; row < rows; row++ {
+		expectedRow, actualRow := rowAt(s.Rows, row), rowAt(actual.Rows, row)
+		columns := len(expectedRow)
+		if len(actualRow) > columns {
+			columns = len(actualRow)
+		}
+		for col := 0; col < columns; col++ {
+			expectedValue, actualValue := valueAt(expectedRow, col), valueAt(actualRow, col)
+			if expectedValue != actualValue {
+				cell, _ := excelize.CoordinatesToCellName(col+1, row+1)
+				lines = append(lines, fmt.Sprintf("%s!%s: expected %q, got %q", s.Name, cell, expectedValue, actualValue))
+			}
+		}
+	}
+	lines = append(lines, diffLists(s.Name+" merged cells", s.MergedCells, actual.MergedCells)...)
+	lines = append(lines, diffCells(s.Name, "number format", s.NumberFormats, actual.NumberFormats)...)
+	lines = append(lines, diffCells(s.Name, "style", s.Styles, actual.Styles)...)
+	return append(lines, diffLists(s.Name+" data validations", s.DataValidations, actual.DataValidations)...)
+}
+
+func rowAt(rows [][]string, i int) []string {
+	if i < len(rows) {
+		return rows[i]
+	}
+	return nil
+}
+
+func valueAt(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// diffLists reports the values missing from actual and the unexpected ones, both lists are sorted.
+func diffLists(what string, expected []string, actual []string) []string {
+	var lines []string
+	for _, value := range expected {
+		if !contains(actual, value) {
+			lines = append(lines, fmt.Sprintf("%s: missing %s", what, value))
+		}
+	}
+	for _, value := range actual {
+		if !contains(expected, value) {
+			lines = append(lines, fmt.Sprintf("%s: unexpected %s", what, value))
+		}
+	}
+	return lines
+}
+
+func diffCells(sheet string, what string, expected map[string]string, actual map[string]string) []string {
+	cells := make(map[string]bool)
+	for cell := range expected {
+		cells[cell] = true
+	}
+	for cell := range actual {
+		cells[cell] = true
+	}
+	names := make([]string, 0, len(cells))
+	for cell := range cells {
+		names = append(names, cell)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return cellLess(names[i], names[j])
+	})
+	var lines []string
+	for _, cell := range names {
+		if expected[cell] != actual[cell] {
+			lines = append(lines, fmt.Sprintf("%s!%s %s: expected %q, got %q", sheet, cell, what, expected[cell], actual[cell]))
+		}
+	}
+	return lines
+}
+
+// cellLess orders cells by row then column, A2 before A10.
+func cellLess(a string, b string) bool {
+	colA, rowA, errA := excelize.CellNameToCoordinates(a)
+	colB, rowB, errB := excelize.CellNameToCoordinates(b)
+	if errA != nil || errB != nil || rowA == rowB && colA == colB {
+		return a < b
+	}
+	if rowA != rowB {
+		return rowA < rowB
+	}
+	return colA < colB
+}
@@ -0,0 +1,100 @@
+package harness
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func newGoldenWorkbook(t *testing.T) *excelize.File {
+	file := excelize.NewFile()
+	assert.Nil(t, file.SetCellValue("Sheet1", "A1", "Supplier"))
+	assert.Nil(t, file.SetCellValue("Sheet1", "AB3", 1.5))
+	assert.Nil(t, file.MergeCell("Sheet1", "A1", "C1"))
+	style, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: stringPtr("0.00")})
+	assert.Nil(t, err)
+	assert.Nil(t, file.SetCellStyle("Sheet1", "A1", "A1", style))
+	validation := excelize.NewDataValidation(true)
+	validation.Sqref = "B2:B10"
+	assert.Nil(t, validation.SetDropList([]string{"yes", "no"}))
+	assert.Nil(t, file.AddDataValidation("Sheet1", validation))
+	_, err = file.NewSheet("Terms")
+	assert.Nil(t, err)
+	assert.Nil(t, file.SetCellValue("Terms", "A1", "MOQ"))
+	return file
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestWorkbookGolden_Update(t *testing.T) {
+	golden := WorkbookGolden{Dir: t.TempDir(), Styles: true, MergedCells: true, NumberFormats: true, DataValidations: true}
+
+	updateGolden(t)
+	golden.Assert(t, "export/terms", newGoldenWorkbook(t))
+	assert.Nil(t, flag.Set("update", "false"))
+	assert.FileExists(t, filepath.Join(golden.Dir, "export/terms.xlsx"))
+	content, err := os.ReadFile(filepath.Join(golden.Dir, "export/terms.golden"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), `"A1": "0.00"`)
+	assert.Contains(t, string(content), `"A1:C1"`)
+
+	golden.Assert(t, "export/terms", newGoldenWorkbook(t))
+}
+
+func TestWorkbookGolden_Diff(t *testing.T) {
+	golden := WorkbookGolden{Styles: true, MergedCells: true, NumberFormats: true, DataValidations: true}
+	expected, err := golden.snapshot(newGoldenWorkbook(t))
+	assert.Nil(t, err)
+
+	file := newGoldenWorkbook(t)
+	assert.Nil(t, file.SetCellValue("Sheet1", "AB3", 2))
+	assert.Nil(t, file.SetCellValue("Sheet1", "AD40", "extra"))
+	assert.Nil(t, file.UnmergeCell("Sheet1", "A1", "C1"))
+	plain, err := file.NewStyle(&excelize.Style{NumFmt: 3})
+	assert.Nil(t, err)
+	assert.Nil(t, file.SetCellStyle("Sheet1", "A1", "A1", plain))
+	file.DeleteSheet("Terms")
+	_, err = file.NewSheet("Sites")
+	assert.Nil(t, err)
+	actual, err := golden.snapshot(file)
+	assert.Nil(t, err)
+
+	diff := expected.diff(actual)
+	assert.Len(t, diff, 7)
+	assert.Equal(t, []string{
+		`Sheet1!AB3: expected "1.5", got "2"`,
+		`Sheet1!AD40: expected "", got "extra"`,
+		`Sheet1 merged cells: missing A1:C1`,
+		`Sheet1!A1 number format: expected "0.00", got "3"`,
+	}, diff[:4])
+	// The style lines hold the styles as JSON
+	assert.Contains(t, diff[4], `Sheet1!A1 style: expected "{`)
+	assert.Equal(t, []string{`sheet "Terms" is missing`, `unexpected sheet "Sites"`}, diff[5:])
+}
+
+func TestWorkbookGolden_Sheets(t *testing.T) {
+	golden := WorkbookGolden{Sheets: []string{"Terms"}}
+	expected, err := golden.snapshot(newGoldenWorkbook(t))
+	assert.Nil(t, err)
+	if assert.Len(t, expected.Sheets, 1) {
+		assert.Equal(t, "Terms", expected.Sheets[0].Name)
+	}
+
+	file := newGoldenWorkbook(t)
+	assert.Nil(t, file.SetCellValue("Sheet1", "A1", "changed"))
+	actual, err := golden.snapshot(file)
+	assert.Nil(t, err)
+	assert.Empty(t, expected.diff(actual))
+}
+
+func TestCellLess(t *testing.T) {
+	assert.True(t, cellLess("B2", "A10"))
+	assert.True(t, cellLess("Z3", "AA3"))
+	assert.False(t, cellLess("AA3", "Z3"))
+}
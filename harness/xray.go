@@ -0,0 +1,239 @@
+package harness
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type (
+	// Xray talks to the Xray for Jira Server REST api. Tests and test executions are
+	// plain jira issues so issue lookups and the http client are shared with Jira.
+	Xray struct {
+		Jira
+	}
+	XrayProject struct {
+		Key string `json:"key"`
+	}
+	XrayIssueType struct {
+		Name string `json:"name"`
+	}
+	XrayIssueFields struct {
+		Project   XrayProject   `json:"project"`
+		Summary   string        `json:"summary"`
+		IssueType XrayIssueType `json:"issuetype"`
+		Labels    []string      `json:"labels,omitempty"`
+	}
+	XrayIssue struct {
+		Fields XrayIssueFields `json:"fields"`
+	}
+	XrayIssueLinkType struct {
+		Name string `json:"name"`
+	}
+	XrayIssueLinkRef struct {
+		Key string `json:"key"`
+	}
+	XrayIssueLink struct {
+		Type         XrayIssueLinkType `json:"type"`
+		InwardIssue  XrayIssueLinkRef  `json:"inwardIssue"`
+		OutwardIssue XrayIssueLinkRef  `json:"outwardIssue"`
+	}
+	XraySearchIssueFields struct {
+		Summary string               `json:"summary"`
+		Status  GetIssueFieldsStatus `json:"status"`
+	}
+	XraySearchIssue struct {
+		Key    string                `json:"key"`
+		Fields XraySearchIssueFields `json:"fields"`
+	}
+	XraySearchResult struct {
+		Issues []*XraySearchIssue `json:"issues"`
+	}
+	XrayExecutionInfo struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		StartDate   string `json:"startDate,omitempty"`
+		FinishDate  string `json:"finishDate,omitempty"`
+	}
+	XrayExecutionTest struct {
+		TestKey   string          `json:"testKey"`
+		Status    string          `json:"status"`
+		Start     string          `json:"start,omitempty"`
+		Finish    string          `json:"finish,omitempty"`
+		Comment   string          `json:"comment,omitempty"`
+		Evidences []*XrayEvidence `json:"evidences,omitempty"`
+	}
+	XrayEvidence struct {
+		Data        string `json:"data"`
+		Filename    string `json:"filename"`
+		ContentType string `json:"contentType"`
+	}
+	XrayExecution struct {
+		Info  XrayExecutionInfo    `json:"info"`
+		Tests []*XrayExecutionTest `json:"tests"`
+	}
+	XrayExecutionResult struct {
+		TestExecIssue CreateResult `json:"testExecIssue"`
+	}
+)
+
+const (
+	xrayTimeLayout    = "2006-01-02T15:04:05.000Z07:00"
+	xrayTestIssueType = "Test"
+	xrayTestLinkType  = "Tests"
+)
+
+func (x *Xray) ListTests(issueKey string) ([]*TestResult, error) {
+	url := x.Url + "/rest/api/2/search"
+	client := x.GetClient()
+	jql := fmt.Sprintf("project = \"%s\" AND issuetype = \"%s\" AND issue in linkedIssues(%s)", x.ProjectKey, xrayTestIssueType, issueKey)
+	req := client.R().
+		SetQueryParam("jql", jql).
+		SetQueryParam("fields", "summary,status").
+		SetResult(&XraySearchResult{})
+	res, err := execute(req, resty.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+	var tests []*TestResult
+	for _, issue := range res.Result().(*XraySearchResult).Issues {
+		tests = append(tests, &TestResult{
+			Key:    issue.Key,
+			Name:   issue.Fields.Summary,
+			Status: issue.Fields.Status.Name,
+		})
+	}
+	return tests, nil
+}
+
+func (x *Xray) CreateTest(testCase *TestCase) (string, error) {
+	url := x.Url + "/rest/api/2/issue"
+	testCase.ProjectKey = x.ProjectKey
+	client := x.GetClient()
+	payload := &XrayIssue{
+		Fields: XrayIssueFields{
+			Project:   XrayProject{Key: x.ProjectKey},
+			Summary:   testCase.Name,
+			IssueType: XrayIssueType{Name: xrayTestIssueType},
+			Labels:    xrayFolderLabels(testCase.Folder),
+		},
+	}
+	req := client.R().
+		SetBody(payload).
+		SetResult(&CreateResult{})
+	resp, err := execute(req, resty.MethodPost, url)
+	if err != nil {
+		return "", err
+	}
+	testKey := resp.Result().(*CreateResult).Key
+	for _, issueKey := range testCase.IssueLinks {
+		link := &XrayIssueLink{
+			Type:         XrayIssueLinkType{Name: xrayTestLinkType},
+			InwardIssue:  XrayIssueLinkRef{Key: testKey},
+			OutwardIssue: XrayIssueLinkRef{Key: issueKey},
+		}
+		if _, err = execute(client.R().SetBody(link), resty.MethodPost, x.Url+"/rest/api/2/issueLink"); err != nil {
+			return testKey, err
+		}
+	}
+	return testKey, nil
+}
+
+func (x *Xray) UpdateTest(testKey string, testCase *TestCase) error {
+	url := fmt.Sprintf("%s/rest/api/2/issue/%s", x.Url, testKey)
+	testCase.ProjectKey = x.ProjectKey
+	client := x.GetClient()
+	payload := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"summary": testCase.Name,
+			"labels":  xrayFolderLabels(testCase.Folder),
+		},
+	}
+	_, err := execute(client.R().SetBody(payload), resty.MethodPut, url)
+	return err
+}
+
+func (x *Xray) DeleteTest(testKey string) error {
+	return x.deleteIssue(testKey)
+}
+
+func (x *Xray) CreateTestCycle(cycle *TestCycle) (string, error) {
+	url := x.Url + "/rest/raven/1.0/import/execution"
+	cycle.ProjectKey = x.ProjectKey
+	client := x.GetClient()
+	payload := &XrayExecution{
+		Info: XrayExecutionInfo{
+			Summary:     cycle.Name,
+			Description: fmt.Sprintf("Automated run of %s", cycle.IssueKey),
+			StartDate:   xrayDate(cycle.PlannedStartDate),
+			FinishDate:  xrayDate(cycle.PlannedEndDate),
+		},
+	}
+	for _, item := range cycle.Items {
+		test := &XrayExecutionTest{
+			TestKey: item.TestCaseKey,
+			Status:  strings.ToUpper(item.Status),
+			Comment: item.Comment,
+		}
+		// Xray derives the duration of a test from its start and finish
+		if start, err := time.ParseInLocation("2006-01-02T15:04:05", cycle.PlannedStartDate, time.Local); err == nil && item.ExecutionTime > 0 {
+			test.Start = start.Format(xrayTimeLayout)
+			test.Finish = start.Add(time.Duration(item.ExecutionTime) * time.Millisecond).Format(xrayTimeLayout)
+		}
+		for _, attachment := range item.Attachments {
+			test.Evidences = append(test.Evidences, &XrayEvidence{
+				Data:        base64.StdEncoding.EncodeToString(attachment.Data),
+				Filename:    attachment.Name,
+				ContentType: attachment.ContentType,
+			})
+		}
+		payload.Tests = append(payload.Tests, test)
+	}
+	req := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(payload).
+		SetResult(&XrayExecutionResult{})
+	resp, err := execute(req, resty.MethodPost, url)
+	if err != nil {
+		return "", err
+	}
+	return resp.Result().(*XrayExecutionResult).TestExecIssue.Key, nil
+}
+
+func (x *Xray) DeleteTestCycle(cycleKey string) error {
+	return x.deleteIssue(cycleKey)
+}
+
+// CreateFolder is a no-op, Xray tests are grouped by the labels set in CreateTest.
+func (x *Xray) CreateFolder(string) error {
+	return nil
+}
+
+func (x *Xray) deleteIssue(issueKey string) error {
+	url := fmt.Sprintf("%s/rest/api/2/issue/%s", x.Url, issueKey)
+	client := x.GetClient()
+	_, err := execute(client.R(), resty.MethodDelete, url)
+	return err
+}
+
+func xrayFolderLabels(folder string) []string {
+	var labels []string
+	for _, part := range strings.Split(folder, "/") {
+		if part != "" {
+			labels = append(labels, part)
+		}
+	}
+	return labels
+}
+
+// xrayDate converts the local cycle date into the ISO 8601 layout with offset Xray expects.
+func xrayDate(date string) string {
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", date, time.Local)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
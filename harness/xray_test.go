@@ -0,0 +1,267 @@
+package harness
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// fakeXray is an in-memory Xray for Jira Server, its tests are issues linked to the
+	// issues of the embedded fakeJira.
+	fakeXray struct {
+		*fakeJira
+		tests      map[string]*fakeXrayTest
+		executions map[string]*XrayExecution
+	}
+	fakeXrayTest struct {
+		Fields XrayIssueFields
+		Links  []string
+	}
+)
+
+var linkedIssuesQuery = regexp.MustCompile(`linkedIssues\(([^)]*)\)`)
+
+func newFakeXray(projectKey string) *fakeXray {
+	x := &fakeXray{
+		fakeJira: &fakeJira{
+			fakeBackend: newFakeBackend(projectKey),
+			issues:      make(map[string]*fakeIssue),
+		},
+		tests:      make(map[string]*fakeXrayTest),
+		executions: make(map[string]*XrayExecution),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/issue", x.handleCreateIssue)
+	mux.HandleFunc("/rest/api/2/issue/", x.handleIssue)
+	mux.HandleFunc("/rest/api/2/issueLink", x.handleIssueLink)
+	mux.HandleFunc("/rest/api/2/search", x.handleSearch)
+	mux.HandleFunc("/rest/raven/1.0/import/execution", x.handleImportExecution)
+	x.Server = httptest.NewServer(x.withFailures(mux, fakeRoute))
+	return x
+}
+
+func (x *fakeXray) client() *Xray {
+	return &Xray{
+		Jira: Jira{
+			HttpClientConfig: HttpClientConfig{
+				RetryWaitTime: time.Millisecond,
+				RateLimit:     1000,
+			},
+			ProjectKey: x.projectKey,
+			UserName:   "user",
+			Password:   "password",
+			Url:        x.URL,
+		},
+	}
+}
+
+// addTest stores a test linked to issueKeys as if it was created by an earlier run.
+func (x *fakeXray) addTest(name string, issueKeys ...string) string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	key := x.nextKey("T")
+	x.tests[key] = &fakeXrayTest{Fields: XrayIssueFields{Summary: name}, Links: issueKeys}
+	return key
+}
+
+func (x *fakeXray) test(key string) *fakeXrayTest {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.tests[key]
+}
+
+func (x *fakeXray) execution(key string) *XrayExecution {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.executions[key]
+}
+
+func (x *fakeXray) handleCreateIssue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	issue := &XrayIssue{}
+	if err := json.NewDecoder(r.Body).Decode(issue); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	key := x.nextKey("T")
+	x.tests[key] = &fakeXrayTest{Fields: issue.Fields}
+	writeJson(w, http.StatusCreated, &CreateResult{Key: key})
+}
+
+// handleIssue reads the issues of fakeJira, and updates or deletes the tests.
+func (x *fakeXray) handleIssue(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		x.fakeJira.handleIssue(w, r)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+	update := &XrayIssue{}
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(update); err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+			return
+		}
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	test, ok := x.tests[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		test.Fields.Summary = update.Fields.Summary
+		test.Fields.Labels = update.Fields.Labels
+		x.updated = append(x.updated, key)
+	case http.MethodDelete:
+		delete(x.tests, key)
+		x.deleted = append(x.deleted, key)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (x *fakeXray) handleIssueLink(w http.ResponseWriter, r *http.Request) {
+	link := &XrayIssueLink{}
+	if err := json.NewDecoder(r.Body).Decode(link); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	test, ok := x.tests[link.InwardIssue.Key]
+	if !ok || link.Type.Name != xrayTestLinkType {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{"invalid link"}})
+		return
+	}
+	test.Links = append(test.Links, link.OutwardIssue.Key)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (x *fakeXray) handleSearch(w http.ResponseWriter, r *http.Request) {
+	match := linkedIssuesQuery.FindStringSubmatch(r.URL.Query().Get("jql"))
+	if match == nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{"invalid jql"}})
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	keys := make([]string, 0, len(x.tests))
+	for key := range x.tests {
+		keys = append(keys, key)
+	}
+	result := &XraySearchResult{Issues: make([]*XraySearchIssue, 0)}
+	for _, key := range sortKeys(keys) {
+		test := x.tests[key]
+		for _, link := range test.Links {
+			if link == match[1] {
+				result.Issues = append(result.Issues, &XraySearchIssue{
+					Key: key,
+					Fields: XraySearchIssueFields{
+						Summary: test.Fields.Summary,
+						Status:  GetIssueFieldsStatus{Name: "Open"},
+					},
+				})
+				break
+			}
+		}
+	}
+	writeJson(w, http.StatusOK, result)
+}
+
+func (x *fakeXray) handleImportExecution(w http.ResponseWriter, r *http.Request) {
+	execution := &XrayExecution{}
+	if err := json.NewDecoder(r.Body).Decode(execution); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]interface{}{"errorMessages": []string{err.Error()}})
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	key := x.nextKey("E")
+	x.executions[key] = execution
+	writeJson(w, http.StatusOK, &XrayExecutionResult{TestExecIssue: CreateResult{Key: key}})
+}
+
+func TestXray_PushTests(t *testing.T) {
+	t.Setenv(removeOldTestEnv, "")
+	x := newFakeXray(fakeProjectKey)
+	defer x.Close()
+	x.addIssue("OMNI-1", &fakeIssue{Status: "Open", Epic: "Demand planning"})
+	reusedKey := x.addTest("happy case", "OMNI-1")
+
+	testCases := []*TestCase{
+		{Name: "happy case", Status: "Pass"},
+		{Name: "error case", Status: "Fail", Comment: "expected: 1, actual: 2", Logs: "request: {}\n"},
+	}
+	err := NewTestData(x.client()).PushTests("OMNI-1", fakeApiName, fakeFolder, testCases)
+	assert.Nil(t, err)
+
+	labels := []string{"HN7", "API", "SC", fakeFolder}
+	assert.Equal(t, []string{reusedKey}, x.updatedKeys())
+	assert.Equal(t, &fakeXrayTest{
+		Fields: XrayIssueFields{Summary: "happy case", Labels: labels},
+		Links:  []string{"OMNI-1"},
+	}, x.test(reusedKey))
+	assert.Equal(t, &fakeXrayTest{
+		Fields: XrayIssueFields{
+			Project:   XrayProject{Key: fakeProjectKey},
+			Summary:   "error case",
+			IssueType: XrayIssueType{Name: xrayTestIssueType},
+			Labels:    labels,
+		},
+		Links: []string{"OMNI-1"},
+	}, x.test("OMNI-T2"))
+	assert.Equal(t, 1, x.requestCount("POST /rest/api/2/issueLink"))
+
+	execution := x.execution("OMNI-E3")
+	if assert.NotNil(t, execution) {
+		assert.Equal(t, "[Demand planning][OMNI-1]GetDemandPlanning", execution.Info.Summary)
+		assert.Equal(t, []*XrayExecutionTest{
+			{TestKey: reusedKey, Status: "PASS"},
+			{TestKey: "OMNI-T2", Status: "FAIL", Comment: "expected: 1, actual: 2", Evidences: []*XrayEvidence{{
+				Data:        base64.StdEncoding.EncodeToString([]byte("request: {}\n")),
+				Filename:    "OMNI-T2.log",
+				ContentType: "text/plain",
+			}}},
+		}, execution.Tests)
+	}
+}
+
+func TestXray_RequestError(t *testing.T) {
+	t.Setenv(removeOldTestEnv, "")
+	x := newFakeXray(fakeProjectKey)
+	defer x.Close()
+	x.addIssue("OMNI-1", &fakeIssue{Status: "Open"})
+	x.failNext("POST /rest/api/2/issue", http.StatusBadRequest)
+
+	err := NewTestData(x.client()).PushTests("OMNI-1", fakeApiName, fakeFolder, []*TestCase{{Name: "happy case", Status: "Pass"}})
+	var reqErr *RequestError
+	if assert.True(t, errors.As(err, &reqErr)) {
+		assert.Equal(t, http.MethodPost, reqErr.Method)
+		assert.Equal(t, x.URL+"/rest/api/2/issue", reqErr.Url)
+		assert.Equal(t, http.StatusBadRequest, reqErr.StatusCode)
+		assert.Contains(t, reqErr.Body, "Bad Request")
+	}
+	assert.Equal(t, 1, x.requestCount("POST /rest/api/2/issue"))
+	// The cycle is still created without the test that failed
+	if execution := x.execution("OMNI-E1"); assert.NotNil(t, execution) {
+		assert.Empty(t, execution.Tests)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"testing"
 
 	"github.com/gogo/protobuf/types"
-	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	exportServiceApi "go.tekoapis.com/tekone/app/aggregator/export-service/api"
@@ -29,6 +28,7 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/pkg/faker"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	whCentralApi "go.tekoapis.com/tekone/app/warehouse/central-service/api"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
@@ -40,6 +40,7 @@ type exportSupplierDeliveryTestSuite struct {
 	faker      *faker.Faker
 	flagClient flagsup.ClientAdapter
 	flags      map[string]bool
+	files      *harness.FakeFileService
 }
 
 func TestExportSupplierDelivery(t *testing.T) {
@@ -49,6 +50,7 @@ func TestExportSupplierDelivery(t *testing.T) {
 	ts.DB = db
 	ts.Faker = faker.New(db)
 	ts.flags = make(map[string]bool)
+	ts.files = harness.NewFakeFileService()
 
 	err := ts.setUp()
 	if err != nil {
@@ -123,10 +125,7 @@ func TestExportSupplierDelivery(t *testing.T) {
 		})
 
 	fileServiceClient := &file_service.FilesClientImpl{}
-	monkey.PatchInstanceMethod(reflect.TypeOf(fileServiceClient), "UploadFile",
-		func(c *file_service.FilesClientImpl, filePath string, fileType string, isSetFileName bool) (*types.StringValue, error) {
-			return helper.StringToProtoString(mockUrl), nil
-		})
+	tests.PatchFileService(ts.files)
 
 	exportServiceClient := &export_service.Client{}
 	monkey.PatchInstanceMethod(reflect.TypeOf(exportServiceClient), "GetExportRequest",
@@ -260,6 +259,13 @@ func (ts *exportSupplierDeliveryTestSuite) setUp() error {
 	return nil
 }
 
+// assertUploaded checks the export succeeded and uploaded a workbook to url.
+func (ts *exportSupplierDeliveryTestSuite) assertUploaded(url string, err error) {
+	ts.Nil(err)
+	_, err = ts.files.OpenExcel(url)
+	ts.Require().NoError(err)
+}
+
 func (ts *exportSupplierDeliveryTestSuite) Test_HappyCase() {
 	url, err := ts.worker.ExportSuppliers(context.Background(), &exportServiceApi.ExportEvent{
 		Id:         1,
@@ -269,8 +275,7 @@ func (ts *exportSupplierDeliveryTestSuite) Test_HappyCase() {
 		Payload:    "{\"seller_id\": 1, \"is_active\": {\"value\": false}}",
 		Status:     exportServiceApi.Status_open.String(),
 	})
-	assert.Nil(ts.T(), err)
-	assert.Equal(ts.T(), mockUrl, url)
+	ts.assertUploaded(url, err)
 }
 
 func (ts *exportSupplierDeliveryTestSuite) Test_HappyCase_1148() {
@@ -283,8 +288,7 @@ func (ts *exportSupplierDeliveryTestSuite) Test_HappyCase_1148() {
 		Payload:    "{\"seller_id\": 1, \"is_active\": {\"value\": false}}",
 		Status:     exportServiceApi.Status_open.String(),
 	})
-	assert.Nil(ts.T(), err)
-	assert.Equal(ts.T(), mockUrl, url)
+	ts.assertUploaded(url, err)
 }
 
 func (ts *exportSupplierDeliveryTestSuite) Test_HappyCase_1589() {
@@ -297,6 +301,5 @@ func (ts *exportSupplierDeliveryTestSuite) Test_HappyCase_1589() {
 		Payload:    "{\"seller_id\": 1, \"is_active\": {\"value\": false}}",
 		Status:     exportServiceApi.Status_open.String(),
 	})
-	assert.Nil(ts.T(), err)
-	assert.Equal(ts.T(), mockUrl, url)
+	ts.assertUploaded(url, err)
 }
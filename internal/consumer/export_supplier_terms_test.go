@@ -1,18 +1,13 @@
 package consumer
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"flag"
 	"fmt"
-	"os"
 	"reflect"
 	"testing"
 
-	"github.com/360EntSecGroup-Skylar/excelize/v3"
 	"github.com/gogo/protobuf/types"
-	"github.com/sebdah/goldie/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
@@ -22,7 +17,6 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/api"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/internal/adapter/catalog_grpc"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/internal/adapter/export_service"
-	"go.tekoapis.com/tekone/app/supplychain/supplier_service/internal/adapter/file_service"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/internal/adapter/flagsup"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/internal/adapter/seller_service"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/internal/adapter/supplychain"
@@ -39,12 +33,11 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/pkg/faker"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	whCentralApi "go.tekoapis.com/tekone/app/warehouse/central-service/api"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
 
-var isUpdateExcelFile = flag.Bool("update-excel", false, "is update or not")
-
 type exportSupplierTermsTestSuite struct {
 	tests.TestSuite
 	suppliers  []model.Supplier
@@ -56,15 +49,8 @@ type exportSupplierTermsTestSuite struct {
 	respGetSupplierDeliveryLayerSiteSupplier    []*medusaApi.SupplierSiteDeliveryLine
 	respGetSupplierDeliveryLayerSkuSiteSupplier []*medusaApi.SupplierSiteDeliveryLine
 
-	isGenNewFile  bool
-	mapResultFile map[string]*excelize.File
-	flagList      map[string]bool
-}
-
-type sheetDataCompareGoldie struct {
-	SheetGeneral         [][]string
-	SheetSiteSupplier    [][]string
-	SheetSkuSiteSupplier [][]string
+	files    *harness.FakeFileService
+	flagList map[string]bool
 }
 
 func TestExportSupplierTerms(t *testing.T) {
@@ -74,14 +60,10 @@ func TestExportSupplierTerms(t *testing.T) {
 	ts.DB = db
 	ts.Faker = faker.New(db)
 	ts.Context = context.Background()
-	ts.mapResultFile = make(map[string]*excelize.File)
+	ts.files = harness.NewFakeFileService()
 	ts.tearDown()
 	ts.flagList = make(map[string]bool)
 
-	if isUpdateExcelFile != nil {
-		ts.isGenNewFile = *isUpdateExcelFile
-	}
-
 	supplyChainClient := &supplychain.Client{}
 	monkey.PatchInstanceMethod(reflect.TypeOf(supplyChainClient), "GetSupplierDeliveryWithBatch",
 		func(c *supplychain.Client, ctx context.Context, req *medusaApi.GetSupplierDeliveryRequest) (*medusaApi.GetSupplierDeliveryResponse, error) {
@@ -135,17 +117,7 @@ func TestExportSupplierTerms(t *testing.T) {
 			}, nil
 		})
 
-	fileServiceClient := &file_service.FilesClientImpl{}
-	monkey.PatchInstanceMethod(reflect.TypeOf(fileServiceClient), "UploadFile",
-		func(c *file_service.FilesClientImpl, filePath string, contentType string, isSetFileName bool) (*types.StringValue, error) {
-			fileReader, err := os.ReadFile(filePath)
-			assert.Nil(ts.T(), err)
-			file, err := excelize.OpenReader(bytes.NewReader(fileReader))
-			assert.Nil(ts.T(), err)
-			ts.mapResultFile[filePath] = file
-
-			return helper.StringToProtoString(filePath), nil
-		})
+	tests.PatchFileService(ts.files)
 
 	exportServiceClient := &export_service.Client{}
 	monkey.PatchInstanceMethod(reflect.TypeOf(exportServiceClient), "GetExportRequest",
@@ -292,76 +264,9 @@ func (ts *exportSupplierTermsTestSuite) assert(req exportServiceApi.PayloadSCExp
 		Status:     exportServiceApi.Status_open.String(),
 	})
 	assert.Nil(ts.T(), err)
-	expectedPath := fmt.Sprintf("test_data/export_supplier_terms/%s", expectFileName)
-	if ts.isGenNewFile {
-		_ = ts.mapResultFile[actualUrl].SaveAs(fmt.Sprintf("%s.xlsx", expectedPath))
-	}
-	fileReader, err := os.ReadFile(fmt.Sprintf("%s.xlsx", expectedPath))
-	assert.Nil(ts.T(), err)
-	expected, err := excelize.OpenReader(bytes.NewReader(fileReader))
-	assert.Nil(ts.T(), err)
-	actual := ts.mapResultFile[actualUrl]
-	actualData := sheetDataCompareGoldie{}
-
-	// assert sheet general
-	if req.IsExportSupplierTerms {
-		cols, err := actual.GetRows(exports.ExportSupplierTermsSheetGeneral)
-		assert.Nil(ts.T(), err)
-		actualData.SheetGeneral = cols
-
-		maxRow := 30
-		maxColSheetGeneral := 13
-		for row := 1; row < maxRow; row++ {
-			for col := 1; col < maxColSheetGeneral; col++ {
-				colName, _ := excelize.ColumnNumberToName(col)
-				rs1, err := helper.GetCell(colName, row, expected, exports.ExportSupplierTermsSheetGeneral)
-				assert.Nil(ts.T(), err)
-				rs2, err := helper.GetCell(colName, row, actual, exports.ExportSupplierTermsSheetGeneral)
-				assert.Nil(ts.T(), err)
-				assert.Equal(ts.T(), rs1, rs2, fmt.Sprintf("Row %d Col %d", row, col))
-			}
-		}
-	}
-
-	// assert sheet site supplier and sku site supplier
-	if req.IsExportSiteSkuSupplierTerms {
-		// assert sheet site supplier
-		cols, err := actual.GetRows(exports.ExportSupplierTermsSheetSiteSupplier)
-		assert.Nil(ts.T(), err)
-		actualData.SheetSiteSupplier = cols
-
-		maxRow := 30
-		maxColSheetSiteSupplier := 8
-		for row := 1; row < maxRow; row++ {
-			for col := 1; col < maxColSheetSiteSupplier; col++ {
-				colName, _ := excelize.ColumnNumberToName(col)
-				rs1, err := helper.GetCell(colName, row, expected, exports.ExportSupplierTermsSheetSiteSupplier)
-				assert.Nil(ts.T(), err)
-				rs2, err := helper.GetCell(colName, row, actual, exports.ExportSupplierTermsSheetSiteSupplier)
-				assert.Nil(ts.T(), err)
-				assert.Equal(ts.T(), rs1, rs2, fmt.Sprintf("Row %d Col %d", row, col))
-			}
-		}
-
-		// assert sheet sku site supplier
-		cols, err = actual.GetRows(exports.ExportSupplierTermsSheetSkuSiteSupplier)
-		assert.Nil(ts.T(), err)
-		actualData.SheetSkuSiteSupplier = cols
-
-		maxColSheetSkuSiteSupplier := 9
-		for row := 1; row < maxRow; row++ {
-			for col := 1; col < maxColSheetSkuSiteSupplier; col++ {
-				colName, _ := excelize.ColumnNumberToName(col)
-				rs1, err := helper.GetCell(colName, row, expected, exports.ExportSupplierTermsSheetSkuSiteSupplier)
-				assert.Nil(ts.T(), err)
-				rs2, err := helper.GetCell(colName, row, actual, exports.ExportSupplierTermsSheetSkuSiteSupplier)
-				assert.Nil(ts.T(), err)
-				assert.Equal(ts.T(), rs1, rs2, fmt.Sprintf("Row %d Col %d", row, col))
-			}
-		}
-	}
-
-	goldie.New(ts.T()).AssertJson(ts.T(), fmt.Sprintf("export_supplier_terms/%s", expectFileName), actualData)
+	actual, err := ts.files.OpenExcel(actualUrl)
+	ts.Require().NoError(err)
+	harness.WorkbookGolden{Dir: "test_data", MergedCells: true}.Assert(ts.T(), fmt.Sprintf("export_supplier_terms/%s", expectFileName), actual)
 }
 
 func (ts *exportSupplierTermsTestSuite) Test200_ExportSheetThongTinChung_WithoutFilter() {
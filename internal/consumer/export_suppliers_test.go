@@ -6,7 +6,6 @@ import (
 	"testing"
 
 	"github.com/gogo/protobuf/types"
-	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	exportServiceApi "go.tekoapis.com/tekone/app/aggregator/export-service/api"
@@ -29,12 +28,11 @@ import (
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/pkg/faker"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/pkg/helper"
 	"go.tekoapis.com/tekone/app/supplychain/supplier_service/tests"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
 	whCentralApi "go.tekoapis.com/tekone/app/warehouse/central-service/api"
 	"go.tekoapis.com/tekone/library/test/monkey"
 )
 
-const mockUrl = "www.nice-url.com/this-is-an-url"
-
 type exportSuppliersTestSuite struct {
 	tests.TestSuite
 	suppliers  []model.Supplier
@@ -42,6 +40,7 @@ type exportSuppliersTestSuite struct {
 	faker      *faker.Faker
 	flagClient flagsup.ClientAdapter
 	flags      map[string]bool
+	files      *harness.FakeFileService
 }
 
 func TestExportSuppliers(t *testing.T) {
@@ -51,6 +50,7 @@ func TestExportSuppliers(t *testing.T) {
 	ts.DB = db
 	ts.Faker = faker.New(db)
 	ts.flags = make(map[string]bool)
+	ts.files = harness.NewFakeFileService()
 
 	err := ts.setUp()
 	if err != nil {
@@ -125,10 +125,7 @@ func TestExportSuppliers(t *testing.T) {
 		})
 
 	fileServiceClient := &file_service.FilesClientImpl{}
-	monkey.PatchInstanceMethod(reflect.TypeOf(fileServiceClient), "UploadFile",
-		func(c *file_service.FilesClientImpl, filePath string, fileType string, isSetFileName bool) (*types.StringValue, error) {
-			return helper.StringToProtoString(mockUrl), nil
-		})
+	tests.PatchFileService(ts.files)
 
 	exportServiceClient := &export_service.Client{}
 	monkey.PatchInstanceMethod(reflect.TypeOf(exportServiceClient), "GetExportRequest",
@@ -261,6 +258,13 @@ func (ts *exportSuppliersTestSuite) setUp() error {
 	return nil
 }
 
+// assertUploaded checks the export succeeded and uploaded a workbook to url.
+func (ts *exportSuppliersTestSuite) assertUploaded(url string, err error) {
+	ts.Nil(err)
+	_, err = ts.files.OpenExcel(url)
+	ts.Require().NoError(err)
+}
+
 func (ts *exportSuppliersTestSuite) Test_HappyCase() {
 	url, err := ts.worker.ExportSuppliers(context.Background(), &exportServiceApi.ExportEvent{
 		Id:         1,
@@ -270,8 +274,7 @@ func (ts *exportSuppliersTestSuite) Test_HappyCase() {
 		Payload:    "{}",
 		Status:     exportServiceApi.Status_open.String(),
 	})
-	assert.Nil(ts.T(), err)
-	assert.Equal(ts.T(), mockUrl, url)
+	ts.assertUploaded(url, err)
 }
 
 func (ts *exportSuppliersTestSuite) Test_HappyCaseEpic1148() {
@@ -284,8 +287,7 @@ func (ts *exportSuppliersTestSuite) Test_HappyCaseEpic1148() {
 		Payload:    "{}",
 		Status:     exportServiceApi.Status_open.String(),
 	})
-	assert.Nil(ts.T(), err)
-	assert.Equal(ts.T(), mockUrl, url)
+	ts.assertUploaded(url, err)
 }
 
 func (ts *exportSuppliersTestSuite) Test_HappyCaseEpic1589() {
@@ -298,6 +300,5 @@ func (ts *exportSuppliersTestSuite) Test_HappyCaseEpic1589() {
 		Payload:    "{}",
 		Status:     exportServiceApi.Status_open.String(),
 	})
-	assert.Nil(ts.T(), err)
-	assert.Equal(ts.T(), mockUrl, url)
+	ts.assertUploaded(url, err)
 }
@@ -0,0 +1,20 @@
+package tests
+
+import (
+	"go.tekoapis.com/tekone/app/supplychain/supplier_service/internal/model"
+	"go.tekoapis.com/tekone/app/supplychain/tests/harness"
+)
+
+// Registry lists the models of the service and the queries the tests show its repositories
+// run, the migration suite checks them against the migrations.
+var Registry = harness.ModelRegistry{
+	Models: []interface{}{
+		model.Supplier{},
+		model.SupplierContact{},
+		model.SupplierContactCategory{},
+	},
+	Queries: []harness.QueryPattern{
+		// SupplierRepository, the suppliers of the seller listed by GetSuppliers
+		{Model: model.Supplier{}, Columns: []string{"seller_id"}},
+	},
+}